@@ -0,0 +1,69 @@
+package decoders
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	DECODER_BAM string = "BAM"
+	DECODER_DAM string = "DAM"
+)
+
+// bamBookkeepingValues sit next to the entries of a UserSettings key. Every
+// other value is an executable path or the name of a packaged app.
+var bamBookkeepingValues = []string{"Version", "SequenceNumber"}
+
+func init() {
+
+	for _, name := range []string{DECODER_BAM, DECODER_DAM} {
+		source := name
+		Register(&Decoder{
+			Name:    source,
+			Matcher: Matcher{Path: "**\\Services\\" + source + "\\**\\UserSettings\\S-*", Types: []string{utils.STR_REG_BINARY}},
+			Decode: func(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+				return decodeBAM(source, reg, data)
+			},
+		})
+	}
+}
+
+// BAMSid returns the user SID a BAM/DAM UserSettings key belongs to.
+func BAMSid(path string) string {
+
+	return path[strings.LastIndex(path, "\\")+1:]
+}
+
+func decodeBAM(source string, reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	if slices.ContainsFunc(bamBookkeepingValues, func(name string) bool { return strings.EqualFold(name, reg.Name) }) {
+		return nil, fmt.Errorf("not an execution entry")
+	}
+
+	lastRun, err := ParseFiletime(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sid := BAMSid(reg.Path)
+
+	return &entities.DecodedValue{
+		Summary: fmt.Sprintf("%s, last run %s", reg.Name, utils.FormatTime(lastRun)),
+		Fields: []*entities.DecodedField{
+			field("SID", sid),
+			field("Path", reg.Name),
+			field("Last Run", utils.FormatTime(lastRun)),
+		},
+		Events: []*entities.TimelineEvent{{
+			Time:        lastRun,
+			Source:      source,
+			Path:        reg.Path,
+			Name:        reg.Name,
+			Description: fmt.Sprintf("Last run of %s by %s", reg.Name, sid),
+		}},
+	}, nil
+}
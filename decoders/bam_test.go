@@ -0,0 +1,43 @@
+package decoders
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const STR_TEST_BAM_KEY string = "HKEY_LOCAL_MACHINE\\SYSTEM\\ControlSet001\\Services\\bam\\State\\UserSettings\\S-1-5-21-1-2-3-1001"
+
+func TestDecodeBAM(t *testing.T) {
+
+	lastRun := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+	data := append(filetimeBytes(lastRun), make([]byte, 16)...)
+
+	tests := []struct {
+		name  string
+		entry string
+		want  bool
+	}{
+		{"executable", "\\Device\\HarddiskVolume3\\Windows\\System32\\cmd.exe", true},
+		{"packaged app", "Microsoft.WindowsCalculator_8wekyb3d8bbwe", true},
+		{"version", "Version", false},
+		{"sequence number", "SequenceNumber", false},
+	}
+
+	for _, tt := range tests {
+
+		reg := &entities.Registry{Path: STR_TEST_BAM_KEY, Name: tt.entry, Type: utils.STR_REG_BINARY, Value: hex.EncodeToString(data)}
+		decoded := Decode(reg)
+
+		if got := decoded != nil && decoded.Decoder == DECODER_BAM; got != tt.want {
+			t.Errorf("%s: decoded by BAM = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		if tt.want && (len(decoded.Events) != 1 || !decoded.Events[0].Time.Equal(lastRun) || decoded.Fields[1].Value != tt.entry) {
+			t.Errorf("%s: decoded %+v", tt.name, *decoded)
+		}
+	}
+}
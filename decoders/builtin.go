@@ -0,0 +1,195 @@
+package decoders
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	DECODER_FILETIME   string = "FILETIME"
+	DECODER_SID        string = "SID"
+	DECODER_GUID       string = "GUID"
+	DECODER_MRULISTEX  string = "MRUListEx"
+	MRULISTEX_TERMINAL uint32 = 0xffffffff
+	SID_MAX_SUBAUTH    int    = 15
+)
+
+var (
+	plausibleTimeMin = time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	plausibleTimeMax = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func init() {
+
+	Register(&Decoder{
+		Name:     DECODER_FILETIME,
+		Matcher:  Matcher{Types: []string{utils.STR_REG_BINARY, utils.STR_REG_QWORD, utils.STR_REG_DEVPROP}},
+		Decode:   decodeFiletime,
+		Fallback: true,
+	})
+
+	Register(&Decoder{
+		Name:     DECODER_SID,
+		Matcher:  Matcher{Types: []string{utils.STR_REG_BINARY}},
+		Decode:   decodeSID,
+		Fallback: true,
+	})
+
+	Register(&Decoder{
+		Name:     DECODER_GUID,
+		Matcher:  Matcher{Names: []string{"*guid*", "*clsid*", "*id"}, Types: []string{utils.STR_REG_BINARY}},
+		Decode:   decodeGUID,
+		Fallback: true,
+	})
+
+	Register(&Decoder{
+		Name:    DECODER_MRULISTEX,
+		Matcher: Matcher{Names: []string{"MRUListEx"}, Types: []string{utils.STR_REG_BINARY}},
+		Decode:  decodeMRUListEx,
+	})
+}
+
+// ParseFiletime decodes an 8 byte FILETIME, rejecting values outside a
+// plausible range so arbitrary 8 byte blobs are not reported as dates.
+func ParseFiletime(data []byte) (time.Time, error) {
+
+	if len(data) < 8 {
+		return time.Time{}, fmt.Errorf("filetime needs 8 bytes, got %d", len(data))
+	}
+
+	t := utils.FiletimeToTime(binary.LittleEndian.Uint64(data))
+	if t.Before(plausibleTimeMin) || t.After(plausibleTimeMax) {
+		return time.Time{}, fmt.Errorf("filetime out of range")
+	}
+
+	return t, nil
+}
+
+// ParseSID decodes a binary security identifier and returns it with the
+// number of bytes it occupies.
+func ParseSID(data []byte) (string, int, error) {
+
+	if len(data) < 8 || data[0] != 1 {
+		return "", 0, fmt.Errorf("not a sid")
+	}
+
+	count := int(data[1])
+	size := 8 + 4*count
+	if count == 0 || count > SID_MAX_SUBAUTH || len(data) < size {
+		return "", 0, fmt.Errorf("invalid sid sub authority count %d", count)
+	}
+
+	var authority uint64
+	for _, b := range data[2:8] {
+		authority = authority<<8 | uint64(b)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("S-1-")
+	sb.WriteString(strconv.FormatUint(authority, 10))
+	for i := 0; i < count; i++ {
+		sb.WriteString("-")
+		sb.WriteString(strconv.FormatUint(uint64(binary.LittleEndian.Uint32(data[8+4*i:])), 10))
+	}
+
+	return sb.String(), size, nil
+}
+
+// ParseGUID formats a 16 byte little-endian GUID in registry notation.
+func ParseGUID(data []byte) (string, error) {
+
+	if len(data) < 16 {
+		return "", fmt.Errorf("guid needs 16 bytes, got %d", len(data))
+	}
+
+	return fmt.Sprintf("{%08X-%04X-%04X-%X-%X}",
+		binary.LittleEndian.Uint32(data[0:]), binary.LittleEndian.Uint16(data[4:]), binary.LittleEndian.Uint16(data[6:]),
+		data[8:10], data[10:16]), nil
+}
+
+// ParseMRUListEx returns the item indexes of an MRUListEx value, most recent first.
+func ParseMRUListEx(data []byte) []uint32 {
+
+	order := make([]uint32, 0, len(data)/4)
+	for i := 0; i+4 <= len(data); i += 4 {
+		idx := binary.LittleEndian.Uint32(data[i:])
+		if idx == MRULISTEX_TERMINAL {
+			break
+		}
+		order = append(order, idx)
+	}
+	return order
+}
+
+func decodeFiletime(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	if len(data) != 8 {
+		return nil, fmt.Errorf("not a filetime")
+	}
+
+	t, err := ParseFiletime(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.DecodedValue{
+		Summary: utils.FormatTime(t),
+		Fields:  []*entities.DecodedField{field("Time", utils.FormatTime(t))},
+	}, nil
+}
+
+func decodeSID(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	// only NT authority style SIDs, to keep short blobs from decoding by accident
+	if len(data) < 12 || data[2] != 0 || data[3] != 0 || data[4] != 0 || data[5] != 0 || data[6] != 0 {
+		return nil, fmt.Errorf("not a sid")
+	}
+
+	sid, size, err := ParseSID(data)
+	if err != nil || size != len(data) {
+		return nil, fmt.Errorf("not a sid")
+	}
+
+	return &entities.DecodedValue{
+		Summary: sid,
+		Fields:  []*entities.DecodedField{field("SID", sid)},
+	}, nil
+}
+
+func decodeGUID(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	if len(data) != 16 {
+		return nil, fmt.Errorf("not a guid")
+	}
+
+	guid, err := ParseGUID(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.DecodedValue{
+		Summary: guid,
+		Fields:  []*entities.DecodedField{field("GUID", guid)},
+	}, nil
+}
+
+func decodeMRUListEx(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	order := ParseMRUListEx(data)
+
+	items := make([]string, len(order))
+	for i, idx := range order {
+		items[i] = strconv.FormatUint(uint64(idx), 10)
+	}
+
+	return &entities.DecodedValue{
+		Summary: "order " + strings.Join(items, ", "),
+		Fields:  []*entities.DecodedField{field("Order", strings.Join(items, ", "))},
+	}, nil
+}
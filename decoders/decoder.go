@@ -0,0 +1,181 @@
+package decoders
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+// Matcher selects the values a decoder applies to, empty fields match anything.
+// Path is matched with utils.MatchPathPattern against the key path and its
+// aliases, Names with utils.MatchWildcard against the value name.
+type Matcher struct {
+	Path  string
+	Names []string
+	Types []string
+}
+
+type DecodeFunc func(reg *entities.Registry, data []byte) (*entities.DecodedValue, error)
+
+type Decoder struct {
+	Name    string
+	Matcher Matcher
+	Decode  DecodeFunc
+
+	// Fallback decoders are only tried when no specific decoder succeeded.
+	Fallback bool
+}
+
+var (
+	registeredDecoders   []*Decoder
+	registeredDecodersMu sync.RWMutex
+)
+
+func Register(d *Decoder) {
+
+	registeredDecodersMu.Lock()
+	defer registeredDecodersMu.Unlock()
+
+	registeredDecoders = append(registeredDecoders, d)
+}
+
+func Registered() []*Decoder {
+
+	registeredDecodersMu.RLock()
+	defer registeredDecodersMu.RUnlock()
+
+	result := make([]*Decoder, len(registeredDecoders))
+	copy(result, registeredDecoders)
+	return result
+}
+
+// Decode runs the first matching decoder that accepts the value's data and
+// returns nil when no decoder applies.
+func Decode(reg *entities.Registry) *entities.DecodedValue {
+
+	if reg.Name == "" && reg.Type == "" {
+		return nil
+	}
+
+	registeredDecodersMu.RLock()
+	defer registeredDecodersMu.RUnlock()
+
+	var data []byte
+	var dataErr error
+	dataRead := false
+
+	for _, fallback := range []bool{false, true} {
+		for _, d := range registeredDecoders {
+
+			if d.Fallback != fallback || !d.Matcher.Matches(reg) {
+				continue
+			}
+
+			if !dataRead {
+				data, dataErr = RawData(reg)
+				dataRead = true
+			}
+			if dataErr != nil {
+				return nil
+			}
+
+			decoded, err := d.Decode(reg, data)
+			if err != nil || decoded == nil {
+				continue
+			}
+			if decoded.Decoder == "" {
+				decoded.Decoder = d.Name
+			}
+			return decoded
+		}
+	}
+
+	return nil
+}
+
+func (m *Matcher) Matches(reg *entities.Registry) bool {
+
+	if len(m.Types) > 0 {
+		found := false
+		for _, t := range m.Types {
+			if reg.Type == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(m.Names) > 0 {
+		found := false
+		for _, n := range m.Names {
+			if utils.MatchWildcard(n, reg.Name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if m.Path == "" || utils.MatchPathPattern(m.Path, reg.Path) {
+		return true
+	}
+
+	for _, alias := range reg.Aliases {
+		if utils.MatchPathPattern(m.Path, alias) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RawData recovers the value bytes from the textual form the repositories
+// store in entities.Registry.Value.
+func RawData(reg *entities.Registry) ([]byte, error) {
+
+	switch reg.Type {
+
+	case utils.STR_REG_BINARY, utils.STR_REG_FULL_RESOURCE_DESCRIPTOR, utils.STR_REG_DEVPROP:
+		return hex.DecodeString(reg.Value)
+
+	case utils.STR_REG_DWORD, utils.STR_REG_DWORD_BIG_ENDIAN:
+		v, err := strconv.ParseUint(reg.Value, 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dword %q: %w", reg.Value, err)
+		}
+		data := make([]byte, 4)
+		if reg.Type == utils.STR_REG_DWORD_BIG_ENDIAN {
+			binary.BigEndian.PutUint32(data, uint32(v))
+		} else {
+			binary.LittleEndian.PutUint32(data, uint32(v))
+		}
+		return data, nil
+
+	case utils.STR_REG_QWORD:
+		v, err := strconv.ParseUint(reg.Value, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid qword %q: %w", reg.Value, err)
+		}
+		data := make([]byte, 8)
+		binary.LittleEndian.PutUint64(data, v)
+		return data, nil
+
+	default:
+		return []byte(reg.Value), nil
+	}
+}
+
+func field(name string, value string) *entities.DecodedField {
+
+	return &entities.DecodedField{Name: name, Value: value}
+}
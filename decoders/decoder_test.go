@@ -0,0 +1,268 @@
+package decoders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const STR_TEST_KEY string = "HKEY_LOCAL_MACHINE\\SOFTWARE\\Test"
+
+func TestMatcherMatches(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		matcher Matcher
+		reg     entities.Registry
+		want    bool
+	}{
+		{"empty matches anything", Matcher{}, entities.Registry{Path: STR_TEST_KEY, Name: "x", Type: utils.STR_REG_SZ}, true},
+
+		{"exact path", Matcher{Path: STR_TEST_KEY}, entities.Registry{Path: STR_TEST_KEY}, true},
+		{"path is case-insensitive", Matcher{Path: "hkey_local_machine\\software\\TEST"}, entities.Registry{Path: STR_TEST_KEY}, true},
+		{"path does not match a parent", Matcher{Path: STR_TEST_KEY}, entities.Registry{Path: STR_TEST_KEY + "\\Sub"}, false},
+		{"star matches one segment", Matcher{Path: "HKEY_LOCAL_MACHINE\\*\\Test"}, entities.Registry{Path: STR_TEST_KEY}, true},
+		{"star does not span segments", Matcher{Path: "HKEY_LOCAL_MACHINE\\*"}, entities.Registry{Path: STR_TEST_KEY}, false},
+		{"star within a segment", Matcher{Path: "HKEY_LOCAL_MACHINE\\SOFT*\\T?st"}, entities.Registry{Path: STR_TEST_KEY}, true},
+		{"double star spans segments", Matcher{Path: "**\\Test"}, entities.Registry{Path: STR_TEST_KEY}, true},
+		{"double star matches zero segments", Matcher{Path: "HKEY_LOCAL_MACHINE\\**\\SOFTWARE\\Test"}, entities.Registry{Path: STR_TEST_KEY}, true},
+		{"trailing double star", Matcher{Path: "HKEY_LOCAL_MACHINE\\**"}, entities.Registry{Path: STR_TEST_KEY + "\\A\\B"}, true},
+		{"double star then literal mismatch", Matcher{Path: "**\\Other"}, entities.Registry{Path: STR_TEST_KEY}, false},
+		{"path matches an alias", Matcher{Path: "HKEY_CURRENT_USER\\**"}, entities.Registry{Path: "HKEY_USERS\\S-1-5-21-1\\X", Aliases: []string{"HKEY_CURRENT_USER\\X"}}, true},
+		{"path matches no alias", Matcher{Path: "HKEY_CURRENT_CONFIG\\**"}, entities.Registry{Path: STR_TEST_KEY, Aliases: []string{"HKEY_CURRENT_USER\\X"}}, false},
+
+		{"exact name", Matcher{Names: []string{"MRUListEx"}}, entities.Registry{Name: "mrulistex"}, true},
+		{"name glob", Matcher{Names: []string{"*guid*"}}, entities.Registry{Name: "InterfaceGuidValue"}, true},
+		{"name suffix glob", Matcher{Names: []string{"*id"}}, entities.Registry{Name: "AppID"}, true},
+		{"name question mark", Matcher{Names: []string{"?"}}, entities.Registry{Name: "a"}, true},
+		{"name any of several", Matcher{Names: []string{"x", "y*"}}, entities.Registry{Name: "yes"}, true},
+		{"name none of several", Matcher{Names: []string{"x", "y*"}}, entities.Registry{Name: "no"}, false},
+		{"default value name", Matcher{Names: []string{"*"}}, entities.Registry{Name: ""}, true},
+
+		{"type in list", Matcher{Types: []string{utils.STR_REG_BINARY, utils.STR_REG_QWORD}}, entities.Registry{Type: utils.STR_REG_QWORD}, true},
+		{"type not in list", Matcher{Types: []string{utils.STR_REG_BINARY}}, entities.Registry{Type: utils.STR_REG_SZ}, false},
+		{"type is exact", Matcher{Types: []string{utils.STR_REG_DWORD}}, entities.Registry{Type: utils.STR_REG_DWORD_BIG_ENDIAN}, false},
+
+		{"all fields match", Matcher{Path: "**\\Test", Names: []string{"v*"}, Types: []string{utils.STR_REG_SZ}}, entities.Registry{Path: STR_TEST_KEY, Name: "value", Type: utils.STR_REG_SZ}, true},
+		{"one field fails", Matcher{Path: "**\\Test", Names: []string{"v*"}, Types: []string{utils.STR_REG_SZ}}, entities.Registry{Path: STR_TEST_KEY, Name: "other", Type: utils.STR_REG_SZ}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.matcher.Matches(&tt.reg); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRawData(t *testing.T) {
+
+	tests := []struct {
+		valType string
+		value   string
+		want    []byte
+		wantErr bool
+	}{
+		{utils.STR_REG_BINARY, "01ff", []byte{0x01, 0xff}, false},
+		{utils.STR_REG_BINARY, "", []byte{}, false},
+		{utils.STR_REG_BINARY, "0g", nil, true},
+		{utils.STR_REG_BINARY, "abc", nil, true},
+		{utils.STR_REG_FULL_RESOURCE_DESCRIPTOR, "0a0b", []byte{0x0a, 0x0b}, false},
+		{utils.STR_REG_DEVPROP, "00c0", []byte{0x00, 0xc0}, false},
+		{utils.STR_REG_DWORD, "0x00000010", []byte{0x10, 0, 0, 0}, false},
+		{utils.STR_REG_DWORD, "16", []byte{0x10, 0, 0, 0}, false},
+		{utils.STR_REG_DWORD, "0x100000000", nil, true},
+		{utils.STR_REG_DWORD, "", nil, true},
+		{utils.STR_REG_DWORD_BIG_ENDIAN, "0x01020304", []byte{1, 2, 3, 4}, false},
+		{utils.STR_REG_QWORD, "0x0102030405060708", []byte{8, 7, 6, 5, 4, 3, 2, 1}, false},
+		{utils.STR_REG_QWORD, "x", nil, true},
+		{utils.STR_REG_SZ, "text", []byte("text"), false},
+		{utils.STR_REG_EXPAND_SZ, "%SystemRoot%", []byte("%SystemRoot%"), false},
+		{utils.STR_REG_MULTI_SZ, "a, b", []byte("a, b"), false},
+		{utils.STR_NONE, "", []byte{}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := RawData(&entities.Registry{Type: tt.valType, Value: tt.value})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s %q: err = %v, wantErr %v", tt.valType, tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !bytes.Equal(got, tt.want) {
+			t.Errorf("%s %q: data = % x, want % x", tt.valType, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestBuiltinDecoders(t *testing.T) {
+
+	when := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	sid := sidBytes(5, 21, 1, 2, 3, 1001)
+	guid, _ := hex.DecodeString("0114020000000000c000000000000046")
+
+	tests := []struct {
+		name        string
+		reg         entities.Registry
+		wantDecoder string
+		wantSummary string
+	}{
+		{"filetime binary", binaryValue("Time", filetimeBytes(when)), DECODER_FILETIME, "2021-03-04 05:06:07"},
+		{"filetime qword", entities.Registry{Path: STR_TEST_KEY, Name: "Time", Type: utils.STR_REG_QWORD, Value: fmt.Sprintf("0x%016x", binary.LittleEndian.Uint64(filetimeBytes(when)))}, DECODER_FILETIME, "2021-03-04 05:06:07"},
+		{"filetime zero rejected", binaryValue("Time", make([]byte, 8)), "", ""},
+		{"filetime beyond 2100 rejected", binaryValue("Time", filetimeBytes(time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC))), "", ""},
+		{"filetime of 7 bytes rejected", binaryValue("Time", filetimeBytes(when)[:7]), "", ""},
+		{"filetime of 9 bytes rejected", binaryValue("Time", append(filetimeBytes(when), 0)), "", ""},
+		{"filetime dword rejected", entities.Registry{Path: STR_TEST_KEY, Name: "Time", Type: utils.STR_REG_DWORD, Value: "0x00000001"}, "", ""},
+
+		{"sid", binaryValue("Owner", sid), DECODER_SID, "S-1-5-21-1-2-3-1001"},
+		{"sid with trailing bytes rejected", binaryValue("Owner", append(append([]byte(nil), sid...), 0, 0, 0, 0)), "", ""},
+		{"sid truncated rejected", binaryValue("Owner", sid[:len(sid)-4]), "", ""},
+		{"sid revision 2 rejected", binaryValue("Owner", append([]byte{2}, sid[1:]...)), "", ""},
+		{"sid without sub authorities rejected", binaryValue("Owner", []byte{1, 0, 0, 0, 0, 0, 0, 5, 0, 0, 0, 0}), "", ""},
+		{"sid with non NT authority rejected", binaryValue("Owner", append([]byte{1, 1, 0, 0, 1, 0, 0, 5}, sid[8:12]...)), "", ""},
+
+		{"guid", binaryValue("AppID", guid), DECODER_GUID, "{00021401-0000-0000-C000-000000000046}"},
+		{"guid name glob", binaryValue("InterfaceGuid", guid), DECODER_GUID, "{00021401-0000-0000-C000-000000000046}"},
+		{"guid name not matched", binaryValue("Data", guid), "", ""},
+		{"guid of 15 bytes rejected", binaryValue("AppID", guid[:15]), "", ""},
+
+		{"mrulistex", binaryValue("MRUListEx", uint32Bytes(2, 0, 1, MRULISTEX_TERMINAL)), DECODER_MRULISTEX, "order 2, 0, 1"},
+		{"mrulistex stops at terminal", binaryValue("MRUListEx", uint32Bytes(1, MRULISTEX_TERMINAL, 3)), DECODER_MRULISTEX, "order 1"},
+		{"mrulistex wins over a plausible filetime", binaryValue("MRUListEx", filetimeBytes(when)), DECODER_MRULISTEX, ""},
+		{"mrulistex dword rejected", entities.Registry{Path: STR_TEST_KEY, Name: "MRUListEx", Type: utils.STR_REG_DWORD, Value: "0x00000001"}, "", ""},
+		{"mrulist name rejected", binaryValue("MRUList", uint32Bytes(1, MRULISTEX_TERMINAL)), "", ""},
+
+		{"invalid hex", entities.Registry{Path: STR_TEST_KEY, Name: "Time", Type: utils.STR_REG_BINARY, Value: "zz"}, "", ""},
+		{"key entity", entities.Registry{Path: STR_TEST_KEY}, "", ""},
+	}
+
+	for _, tt := range tests {
+		got := Decode(&tt.reg)
+		if tt.wantDecoder == "" {
+			if got != nil {
+				t.Errorf("%s: decoded by %s as %q, want nil", tt.name, got.Decoder, got.Summary)
+			}
+			continue
+		}
+		if got == nil {
+			t.Errorf("%s: not decoded, want %s", tt.name, tt.wantDecoder)
+			continue
+		}
+		if got.Decoder != tt.wantDecoder {
+			t.Errorf("%s: decoder = %s, want %s", tt.name, got.Decoder, tt.wantDecoder)
+		}
+		if tt.wantSummary != "" && got.Summary != tt.wantSummary {
+			t.Errorf("%s: summary = %q, want %q", tt.name, got.Summary, tt.wantSummary)
+		}
+	}
+}
+
+func TestDecodeFallbackPrecedence(t *testing.T) {
+
+	accept := func(summary string) DecodeFunc {
+		return func(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+			return &entities.DecodedValue{Summary: summary}, nil
+		}
+	}
+	reject := func(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+		return nil, fmt.Errorf("rejected")
+	}
+
+	anyValue := Matcher{}
+	testKey := Matcher{Path: STR_TEST_KEY}
+
+	tests := []struct {
+		name     string
+		decoders []*Decoder
+		want     string
+	}{
+		{"specific beats an earlier fallback", []*Decoder{
+			{Name: "fallback", Matcher: anyValue, Decode: accept("fallback"), Fallback: true},
+			{Name: "specific", Matcher: testKey, Decode: accept("specific")},
+		}, "specific"},
+		{"fallback when the specific decoder rejects", []*Decoder{
+			{Name: "fallback", Matcher: anyValue, Decode: accept("fallback"), Fallback: true},
+			{Name: "specific", Matcher: testKey, Decode: reject},
+		}, "fallback"},
+		{"fallback when no specific decoder matches", []*Decoder{
+			{Name: "specific", Matcher: Matcher{Path: "**\\Other"}, Decode: accept("specific")},
+			{Name: "fallback", Matcher: anyValue, Decode: accept("fallback"), Fallback: true},
+		}, "fallback"},
+		{"first registered specific decoder wins", []*Decoder{
+			{Name: "first", Matcher: testKey, Decode: accept("first")},
+			{Name: "second", Matcher: testKey, Decode: accept("second")},
+		}, "first"},
+		{"first registered fallback wins", []*Decoder{
+			{Name: "first", Matcher: anyValue, Decode: accept("first"), Fallback: true},
+			{Name: "second", Matcher: anyValue, Decode: accept("second"), Fallback: true},
+		}, "first"},
+		{"rejecting fallback falls through", []*Decoder{
+			{Name: "first", Matcher: anyValue, Decode: reject, Fallback: true},
+			{Name: "second", Matcher: anyValue, Decode: accept("second"), Fallback: true},
+		}, "second"},
+		{"all reject", []*Decoder{
+			{Name: "specific", Matcher: testKey, Decode: reject},
+			{Name: "fallback", Matcher: anyValue, Decode: reject, Fallback: true},
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		withDecoders(t, tt.decoders)
+		got := Decode(&entities.Registry{Path: STR_TEST_KEY, Name: "v", Type: utils.STR_REG_SZ, Value: "x"})
+		switch {
+		case tt.want == "" && got != nil:
+			t.Errorf("%s: decoded as %q, want nil", tt.name, got.Summary)
+		case tt.want != "" && (got == nil || got.Summary != tt.want || got.Decoder != tt.want):
+			t.Errorf("%s: decoded as %+v, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// withDecoders replaces the registered decoders for the rest of the test.
+func withDecoders(t *testing.T, decoders []*Decoder) {
+
+	registeredDecodersMu.Lock()
+	saved := registeredDecoders
+	registeredDecoders = decoders
+	registeredDecodersMu.Unlock()
+
+	t.Cleanup(func() {
+		registeredDecodersMu.Lock()
+		registeredDecoders = saved
+		registeredDecodersMu.Unlock()
+	})
+}
+
+func binaryValue(name string, data []byte) entities.Registry {
+
+	return entities.Registry{Path: STR_TEST_KEY, Name: name, Type: utils.STR_REG_BINARY, Value: hex.EncodeToString(data)}
+}
+
+func filetimeBytes(t time.Time) []byte {
+
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint64(data, uint64(t.UnixNano()/100)+utils.FILETIME_UNIX_EPOCH_DIFF)
+	return data
+}
+
+func uint32Bytes(values ...uint32) []byte {
+
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], v)
+	}
+	return data
+}
+
+// sidBytes builds a binary SID with the given identifier authority and sub
+// authorities.
+func sidBytes(authority byte, subAuthorities ...uint32) []byte {
+
+	return append([]byte{1, byte(len(subAuthorities)), 0, 0, 0, 0, 0, authority}, uint32Bytes(subAuthorities...)...)
+}
@@ -0,0 +1,60 @@
+package decoders
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+// fieldReader reads consecutive little endian fields and remembers the
+// first overrun so records can be parsed without checking every field.
+type fieldReader struct {
+	data []byte
+	off  int
+	err  error
+}
+
+// bytes returns the next n bytes, or nil once a field overran the data.
+// Lengths come from the record, so nothing is allocated for them.
+func (r *fieldReader) bytes(n int) []byte {
+
+	if r.err != nil || n < 0 || n > len(r.data)-r.off {
+		if r.err == nil {
+			r.err = fmt.Errorf("field at 0x%x truncated", r.off)
+		}
+		return nil
+	}
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *fieldReader) uint16() uint16 {
+
+	if b := r.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *fieldReader) uint32() uint32 {
+
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// sizedString reads a UTF-16 string preceded by its byte length.
+func (r *fieldReader) sizedString() string {
+
+	size := r.uint32()
+	if r.err == nil && uint64(size) > uint64(len(r.data)-r.off) {
+		r.err = fmt.Errorf("string at 0x%x truncated", r.off)
+	}
+	if r.err != nil {
+		return ""
+	}
+	return utils.UTF16ToString(r.bytes(int(size)))
+}
@@ -0,0 +1,34 @@
+package decoders
+
+import (
+	"testing"
+)
+
+func TestFieldReader(t *testing.T) {
+
+	data := join(taskRecord(uint16(7), "ab"), uint32Bytes(0xffffffff))
+	r := &fieldReader{data: data}
+
+	if got := r.uint16(); got != 7 {
+		t.Errorf("uint16 = %d, want 7", got)
+	}
+	if got := r.sizedString(); got != "ab" {
+		t.Errorf("sizedString = %q, want %q", got, "ab")
+	}
+	if got := r.sizedString(); got != "" || r.err == nil {
+		t.Errorf("sizedString with length 0xffffffff = %q, %v, want an error", got, r.err)
+	}
+	if got := r.uint32(); got != 0 {
+		t.Errorf("uint32 after an error = %d, want 0", got)
+	}
+
+	for _, n := range []int{-1, len(data) + 1, 1 << 30} {
+		r := &fieldReader{data: data}
+		if b := r.bytes(n); b != nil || r.err == nil {
+			t.Errorf("bytes(%d) = %d bytes, %v, want nil and an error", n, len(b), r.err)
+		}
+		if b := r.bytes(2); b != nil {
+			t.Errorf("bytes(2) after bytes(%d) failed = %d bytes, want nil", n, len(b))
+		}
+	}
+}
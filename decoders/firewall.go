@@ -0,0 +1,152 @@
+package decoders
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+// Windows Firewall rules are stored as "v2.30|Action=Allow|Dir=In|...|"
+// strings, one value per rule named by the rule id. Address and port fields
+// may repeat.
+
+const (
+	DECODER_FIREWALL_RULE string = "Firewall Rule"
+
+	STR_FIREWALL_RULES_PATTERN string = "HKEY_LOCAL_MACHINE\\SYSTEM\\*\\Services\\SharedAccess\\Parameters\\FirewallPolicy\\**\\FirewallRules"
+)
+
+var firewallProtocols = map[string]string{
+	"1":  "ICMPv4",
+	"2":  "IGMP",
+	"6":  "TCP",
+	"17": "UDP",
+	"41": "IPv6",
+	"47": "GRE",
+	"58": "ICMPv6",
+}
+
+// FirewallRule is a parsed rule string. Fields holds every pair in order,
+// including those without a dedicated field.
+type FirewallRule struct {
+	Version       string
+	Name          string
+	Description   string
+	Group         string
+	Action        string
+	Active        bool
+	Direction     string
+	Protocol      string
+	LocalPorts    []string
+	RemotePorts   []string
+	LocalAddrs    []string
+	RemoteAddrs   []string
+	App           string
+	Service       string
+	PackageFamily string
+	Profiles      []string
+	Fields        []*entities.DecodedField
+}
+
+func init() {
+
+	Register(&Decoder{
+		Name:    DECODER_FIREWALL_RULE,
+		Matcher: Matcher{Path: STR_FIREWALL_RULES_PATTERN, Types: []string{utils.STR_REG_SZ}},
+		Decode:  decodeFirewallRule,
+	})
+}
+
+func ParseFirewallRule(s string) (*FirewallRule, error) {
+
+	parts := strings.Split(strings.TrimSuffix(s, "|"), "|")
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], "v") {
+		return nil, fmt.Errorf("not a firewall rule")
+	}
+
+	// rules are active unless they say otherwise
+	rule := &FirewallRule{Version: parts[0][1:], Active: true}
+
+	for _, part := range parts[1:] {
+
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		rule.Fields = append(rule.Fields, field(key, value))
+
+		switch strings.ToLower(key) {
+		case "name":
+			rule.Name = value
+		case "desc":
+			rule.Description = value
+		case "embedctxt":
+			rule.Group = value
+		case "action":
+			rule.Action = value
+		case "active":
+			rule.Active = strings.EqualFold(value, "TRUE")
+		case "dir":
+			rule.Direction = value
+		case "protocol":
+			rule.Protocol = FirewallProtocolName(value)
+		case "lport", "lport2_10":
+			rule.LocalPorts = append(rule.LocalPorts, value)
+		case "rport", "rport2_10":
+			rule.RemotePorts = append(rule.RemotePorts, value)
+		case "la4", "la6":
+			rule.LocalAddrs = append(rule.LocalAddrs, value)
+		case "ra4", "ra6", "ra42", "ra62":
+			rule.RemoteAddrs = append(rule.RemoteAddrs, value)
+		case "app":
+			rule.App = value
+		case "svc":
+			rule.Service = value
+		case "pfn":
+			rule.PackageFamily = value
+		case "profile":
+			rule.Profiles = append(rule.Profiles, value)
+		}
+	}
+
+	if rule.Action == "" || rule.Direction == "" {
+		return nil, fmt.Errorf("firewall rule without action or direction")
+	}
+
+	return rule, nil
+}
+
+// FirewallProtocolName names an IANA protocol number, leaving others as is.
+func FirewallProtocolName(protocol string) string {
+
+	if name, ok := firewallProtocols[protocol]; ok {
+		return name
+	}
+	return protocol
+}
+
+func decodeFirewallRule(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	rule, err := ParseFirewallRule(reg.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := rule.Action + " " + rule.Direction
+	if rule.Protocol != "" {
+		summary += " " + rule.Protocol
+	}
+	if len(rule.LocalPorts) > 0 {
+		summary += " port " + strings.Join(rule.LocalPorts, ",")
+	}
+	if rule.App != "" {
+		summary += " " + rule.App
+	}
+	if !rule.Active {
+		summary += " (inactive)"
+	}
+
+	return &entities.DecodedValue{Summary: summary, Fields: rule.Fields}, nil
+}
@@ -0,0 +1,95 @@
+package decoders
+
+import "strings"
+
+// knownFolders maps KNOWNFOLDERID values to their canonical names, these show
+// up as path prefixes in UserAssist, jump lists and shell items.
+var knownFolders = map[string]string{
+	"{0139D44E-6AFE-49F2-8690-3DAFCAE6FFB8}": "CommonPrograms",
+	"{0762D272-C50A-4BB0-A382-697DCD729B80}": "UserProfiles",
+	"{1777F761-68AD-4D8A-87BD-30B759FA33DD}": "Favorites",
+	"{18989B1D-99B5-455B-841C-AB7C74E4DDFC}": "Videos",
+	"{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}": "System",
+	"{33E28130-4E1E-4676-835A-98395C3BC3BB}": "Pictures",
+	"{374DE290-123F-4565-9164-39C4925E467B}": "Downloads",
+	"{3EB685DB-65F9-4CF6-A03A-E3EF65729F3D}": "RoamingAppData",
+	"{4BD8D571-6D19-48D3-BE97-422220080E43}": "Music",
+	"{4C5C32FF-BB9D-43B0-B5B4-2D72E54EAAA4}": "SavedGames",
+	"{52A4F021-7B75-48A9-9F6B-4B87A210BC8F}": "QuickLaunch",
+	"{56784854-C6CB-462B-8169-88E350ACB882}": "Contacts",
+	"{5E6C858F-0E22-4760-9AFE-EA3317B67173}": "Profile",
+	"{625B53C3-AB48-4EC1-BA1F-A1EF4146FC19}": "StartMenu",
+	"{62AB5D82-FDC1-4DC3-A9DD-070D1D495D97}": "ProgramData",
+	"{6365D5A7-0F0D-45E5-87F6-0DA56B6A4F7D}": "ProgramFilesCommonX64",
+	"{6D809377-6AF0-444B-8957-A3773F02200E}": "ProgramFilesX64",
+	"{7C5A40EF-A0FB-4BFC-874A-C0F2E0B9FA8E}": "ProgramFilesX86",
+	"{7D1D3A04-DEBB-4115-95CF-2F29DA2920DA}": "Searches",
+	"{82A5EA35-D9CD-47C5-9629-E15D2F714E6E}": "CommonStartup",
+	"{8983036C-27C0-404B-8F08-102D10DCFD74}": "SendTo",
+	"{905E63B6-C1BF-494E-B29C-65B732D3D21A}": "ProgramFiles",
+	"{9E3995AB-1F9C-4F13-B827-48B24B6C7174}": "UserPinned",
+	"{A4115719-D62E-491D-AA7C-E74B8BE3B067}": "CommonStartMenu",
+	"{A520A1A4-1780-4FF6-BD18-167343C5AF16}": "LocalAppDataLow",
+	"{A63293E8-664E-48DB-A079-DF759E0509F7}": "Templates",
+	"{A77F5D77-2E2B-44C3-A6A2-ABA601054A51}": "Programs",
+	"{AE50C081-EBD2-438A-8655-8A092E34987A}": "Recent",
+	"{B4BFCC3A-DB2C-424C-B029-7FE99A87C641}": "Desktop",
+	"{B94237E7-57AC-4347-9151-B08C6C32D1F7}": "CommonTemplates",
+	"{B97D20BB-F46A-4C97-BA10-5E3608430854}": "Startup",
+	"{BFB9D5E0-C6A9-404C-B2B2-AE6DB6AF4968}": "Links",
+	"{C4AA340D-F20F-4863-AFEF-F87EF2E6BA25}": "PublicDesktop",
+	"{D65231B0-B2F1-4857-A4CE-A8E7C6EA7D27}": "SystemX86",
+	"{DE61D971-5EBC-4F02-A3A9-6C82895E5C04}": "AddNewPrograms",
+	"{DE974D24-D9C6-4D3E-BF91-F4455120B917}": "ProgramFilesCommonX86",
+	"{DFDF76A2-C82A-4D63-906A-5644AC457385}": "Public",
+	"{F1B32785-6FBA-4FCF-9D55-7B8E7F157091}": "LocalAppData",
+	"{F38BF404-1D43-42F2-9305-67DE0B28FC23}": "Windows",
+	"{F7F1ED05-9F6D-47A2-AAAE-29D317C6F066}": "ProgramFilesCommon",
+	"{FD228CB7-AE11-4AE3-864C-16F3910AB8FE}": "Fonts",
+	"{FDD39AD0-238F-46AF-ADB4-6C85480369C7}": "Documents",
+}
+
+// shellFolders maps the CLSIDs of shell namespace folders that appear as root
+// items of ID lists.
+var shellFolders = map[string]string{
+	"{018D5C66-4533-4307-9B53-224DE2ED1FE6}": "OneDrive",
+	"{031E4825-7B94-4DC3-B131-E946B44C8DD5}": "Libraries",
+	"{208D2C60-3AEA-1069-A2D7-08002B30309D}": "My Network Places",
+	"{20D04FE0-3AEA-1069-A2D8-08002B30309D}": "My Computer",
+	"{21EC2020-3AEA-1069-A2DD-08002B30309D}": "Control Panel",
+	"{26EE0668-A00A-44D7-9371-BEB064C98683}": "Control Panel",
+	"{450D8FBA-AD25-11D0-98A8-0800361B1103}": "My Documents",
+	"{59031A47-3F72-44A7-89C5-5595FE6B30EE}": "User Files",
+	"{645FF040-5081-101B-9F08-00AA002F954E}": "Recycle Bin",
+	"{679F85CB-0220-4080-B29B-5540CC05AAB6}": "Quick Access",
+	"{871C5380-42A0-1069-A2EA-08002B30309D}": "Internet Explorer",
+	"{F02C1A0D-BE21-4350-88B0-7367FC96EF3C}": "Network",
+	"{F874310E-B6B7-47DC-BC84-B9E6B38F5903}": "Home",
+}
+
+// ShellFolderName resolves a shell namespace or known folder GUID.
+func ShellFolderName(guid string) (string, bool) {
+
+	if name, ok := shellFolders[strings.ToUpper(guid)]; ok {
+		return name, true
+	}
+	return KnownFolderName(guid)
+}
+
+func KnownFolderName(guid string) (string, bool) {
+
+	name, ok := knownFolders[strings.ToUpper(guid)]
+	return name, ok
+}
+
+// ExpandKnownFolderPrefix replaces a leading "{GUID}" with the known folder name.
+func ExpandKnownFolderPrefix(path string) string {
+
+	if len(path) < 38 || path[0] != '{' || path[37] != '}' {
+		return path
+	}
+	if name, ok := KnownFolderName(path[:38]); ok {
+		return "%" + name + "%" + path[38:]
+	}
+	return path
+}
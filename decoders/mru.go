@@ -0,0 +1,159 @@
+package decoders
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	DECODER_RECENTDOCS         string = "RecentDocs"
+	DECODER_OPENSAVEPIDLMRU    string = "OpenSavePidlMRU"
+	DECODER_LASTVISITEDPIDLMRU string = "LastVisitedPidlMRU"
+	DECODER_RUNMRU             string = "RunMRU"
+	DECODER_TYPEDPATHS         string = "TypedPaths"
+
+	STR_EXPLORER_KEY  string = "**\\Software\\Microsoft\\Windows\\CurrentVersion\\Explorer"
+	RUNMRU_TERMINATOR string = "\\1"
+)
+
+// MRUDecoders lists the decoders whose values are items of an MRU list.
+var MRUDecoders = []string{DECODER_RECENTDOCS, DECODER_OPENSAVEPIDLMRU, DECODER_LASTVISITEDPIDLMRU, DECODER_RUNMRU, DECODER_TYPEDPATHS}
+
+func init() {
+
+	Register(&Decoder{
+		Name:    DECODER_RECENTDOCS,
+		Matcher: Matcher{Path: STR_EXPLORER_KEY + "\\RecentDocs\\**", Types: []string{utils.STR_REG_BINARY}},
+		Decode:  decodeRecentDoc,
+	})
+
+	Register(&Decoder{
+		Name:    DECODER_OPENSAVEPIDLMRU,
+		Matcher: Matcher{Path: STR_EXPLORER_KEY + "\\ComDlg32\\OpenSavePidlMRU\\*", Types: []string{utils.STR_REG_BINARY}},
+		Decode:  decodeOpenSavePidl,
+	})
+
+	Register(&Decoder{
+		Name:    DECODER_LASTVISITEDPIDLMRU,
+		Matcher: Matcher{Path: STR_EXPLORER_KEY + "\\ComDlg32\\LastVisitedPidlMRU", Types: []string{utils.STR_REG_BINARY}},
+		Decode:  decodeLastVisitedPidl,
+	})
+
+	Register(&Decoder{
+		Name:    DECODER_RUNMRU,
+		Matcher: Matcher{Path: STR_EXPLORER_KEY + "\\RunMRU", Names: []string{"?"}, Types: []string{utils.STR_REG_SZ, utils.STR_REG_EXPAND_SZ}},
+		Decode:  decodeRunMRU,
+	})
+
+	Register(&Decoder{
+		Name:    DECODER_TYPEDPATHS,
+		Matcher: Matcher{Path: STR_EXPLORER_KEY + "\\TypedPaths", Names: []string{"url*"}, Types: []string{utils.STR_REG_SZ, utils.STR_REG_EXPAND_SZ}},
+		Decode:  decodeTypedPath,
+	})
+}
+
+// ParseRecentDoc returns the file name a RecentDocs item starts with, the
+// shell item of its shortcut follows the terminating null.
+func ParseRecentDoc(data []byte) (string, error) {
+
+	name, _, err := utf16CString(data)
+	return name, err
+}
+
+// ParseLastVisitedPidl splits a LastVisitedPidlMRU item into the executable
+// that showed the dialog and the folder it was last pointed at.
+func ParseLastVisitedPidl(data []byte) (string, string, error) {
+
+	exe, rest, err := utf16CString(data)
+	if err != nil {
+		return "", "", err
+	}
+
+	items, err := ParseIDList(rest)
+	if err != nil {
+		return exe, "", nil
+	}
+
+	return exe, IDListPath(items), nil
+}
+
+// ParseRunMRU strips the "\1" every RunMRU command is stored with.
+func ParseRunMRU(value string) string {
+
+	return strings.TrimSuffix(value, RUNMRU_TERMINATOR)
+}
+
+// utf16CString reads a null terminated UTF-16 string and returns the bytes after it.
+func utf16CString(data []byte) (string, []byte, error) {
+
+	for i := 0; i+1 < len(data); i += 2 {
+		if data[i] == 0 && data[i+1] == 0 {
+			if i == 0 {
+				return "", nil, fmt.Errorf("empty string")
+			}
+			return utils.UTF16ToString(data[:i]), data[i+2:], nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("unterminated string")
+}
+
+func decodeRecentDoc(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	if !IsMRUSlot(reg.Name) {
+		return nil, fmt.Errorf("not an mru slot")
+	}
+
+	name, err := ParseRecentDoc(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.DecodedValue{Summary: name, Fields: []*entities.DecodedField{field("Name", name)}}, nil
+}
+
+func decodeOpenSavePidl(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	if !IsMRUSlot(reg.Name) {
+		return nil, fmt.Errorf("not an mru slot")
+	}
+
+	items, err := ParseIDList(data)
+	if err != nil {
+		return nil, err
+	}
+
+	path := IDListPath(items)
+	return &entities.DecodedValue{Summary: path, Fields: []*entities.DecodedField{field("Path", path)}}, nil
+}
+
+func decodeLastVisitedPidl(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	if !IsMRUSlot(reg.Name) {
+		return nil, fmt.Errorf("not an mru slot")
+	}
+
+	exe, path, err := ParseLastVisitedPidl(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.DecodedValue{
+		Summary: exe + ": " + path,
+		Fields:  []*entities.DecodedField{field("Executable", exe), field("Folder", path)},
+	}, nil
+}
+
+func decodeRunMRU(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	command := ParseRunMRU(reg.Value)
+	return &entities.DecodedValue{Summary: command, Fields: []*entities.DecodedField{field("Command", command)}}, nil
+}
+
+func decodeTypedPath(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	return &entities.DecodedValue{Summary: reg.Value, Fields: []*entities.DecodedField{field("Path", reg.Value)}}, nil
+}
@@ -0,0 +1,263 @@
+package decoders
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+// Account metadata from the SAM hive. Only the fixed F record and the name
+// fields of the V record are read; the V entries holding LM and NT hashes and
+// the password history are never touched.
+
+const (
+	DECODER_SAM_ACCOUNT string = "SAM Account"
+	DECODER_SAM_ALIAS   string = "SAM Alias"
+
+	SAM_F_SIZE           int = 0x44
+	SAM_V_HEADER_SIZE    int = 0xcc
+	SAM_V_ENTRY_SIZE     int = 0x0c
+	SAM_V_USERNAME       int = 1
+	SAM_V_FULLNAME       int = 2
+	SAM_V_COMMENT        int = 3
+	SAM_C_HEADER_SIZE    int = 0x34
+	SAM_DOMAIN_SID_BYTES int = 12
+
+	SAM_ACB_DISABLED uint16 = 0x0001
+	SAM_ACB_PWNOTREQ uint16 = 0x0004
+	SAM_ACB_NORMAL   uint16 = 0x0010
+	SAM_ACB_PWNOEXP  uint16 = 0x0200
+	SAM_ACB_AUTOLOCK uint16 = 0x0400
+)
+
+var samAccountFlags = []struct {
+	flag uint16
+	name string
+}{
+	{SAM_ACB_DISABLED, "Disabled"},
+	{SAM_ACB_PWNOTREQ, "Password not required"},
+	{SAM_ACB_NORMAL, "Normal account"},
+	{SAM_ACB_PWNOEXP, "Password does not expire"},
+	{SAM_ACB_AUTOLOCK, "Locked"},
+}
+
+// SAMAccountF is the fixed length F record of a user account.
+type SAMAccountF struct {
+	RID             uint32
+	Flags           uint16
+	LastLogon       time.Time
+	PasswordLastSet time.Time
+	AccountExpires  time.Time
+	LastFailedLogon time.Time
+	FailedCount     uint16
+	LogonCount      uint16
+}
+
+// SAMAccountV holds the descriptive strings of a V record.
+type SAMAccountV struct {
+	Username string
+	FullName string
+	Comment  string
+}
+
+// SAMAlias is a local group and the SIDs of its members.
+type SAMAlias struct {
+	Name    string
+	Comment string
+	Members []string
+}
+
+func init() {
+
+	Register(&Decoder{
+		Name:    DECODER_SAM_ACCOUNT,
+		Matcher: Matcher{Path: "**\\SAM\\Domains\\Account\\Users\\????????", Names: []string{"F", "V"}, Types: []string{utils.STR_REG_BINARY}},
+		Decode:  decodeSAMAccount,
+	})
+
+	Register(&Decoder{
+		Name:    DECODER_SAM_ALIAS,
+		Matcher: Matcher{Path: "**\\SAM\\Domains\\*\\Aliases\\????????", Names: []string{"C"}, Types: []string{utils.STR_REG_BINARY}},
+		Decode:  decodeSAMAlias,
+	})
+}
+
+func ParseSAMAccountF(data []byte) (*SAMAccountF, error) {
+
+	if len(data) < SAM_F_SIZE {
+		return nil, fmt.Errorf("sam F record needs %d bytes, got %d", SAM_F_SIZE, len(data))
+	}
+
+	f := &SAMAccountF{
+		RID:         binary.LittleEndian.Uint32(data[0x30:]),
+		Flags:       binary.LittleEndian.Uint16(data[0x38:]),
+		FailedCount: binary.LittleEndian.Uint16(data[0x40:]),
+		LogonCount:  binary.LittleEndian.Uint16(data[0x42:]),
+	}
+	f.LastLogon, _ = ParseFiletime(data[0x08:])
+	f.PasswordLastSet, _ = ParseFiletime(data[0x18:])
+	f.AccountExpires, _ = ParseFiletime(data[0x20:])
+	f.LastFailedLogon, _ = ParseFiletime(data[0x28:])
+
+	return f, nil
+}
+
+// ParseSAMAccountV reads the user name, full name and comment entries of the
+// V record's offset table and nothing past them.
+func ParseSAMAccountV(data []byte) (*SAMAccountV, error) {
+
+	if len(data) < SAM_V_HEADER_SIZE {
+		return nil, fmt.Errorf("sam V record needs %d bytes, got %d", SAM_V_HEADER_SIZE, len(data))
+	}
+
+	return &SAMAccountV{
+		Username: samVString(data, SAM_V_USERNAME),
+		FullName: samVString(data, SAM_V_FULLNAME),
+		Comment:  samVString(data, SAM_V_COMMENT),
+	}, nil
+}
+
+func samVString(data []byte, entry int) string {
+
+	off := int(binary.LittleEndian.Uint32(data[entry*SAM_V_ENTRY_SIZE:])) + SAM_V_HEADER_SIZE
+	size := int(binary.LittleEndian.Uint32(data[entry*SAM_V_ENTRY_SIZE+4:]))
+	if size <= 0 || off+size > len(data) {
+		return ""
+	}
+	return utils.UTF16ToString(data[off : off+size])
+}
+
+// ParseSAMAlias decodes the C record of a local group.
+func ParseSAMAlias(data []byte) (*SAMAlias, error) {
+
+	if len(data) < SAM_C_HEADER_SIZE {
+		return nil, fmt.Errorf("sam C record needs %d bytes, got %d", SAM_C_HEADER_SIZE, len(data))
+	}
+
+	str := func(at int) string {
+		off := int(binary.LittleEndian.Uint32(data[at:])) + SAM_C_HEADER_SIZE
+		size := int(binary.LittleEndian.Uint32(data[at+4:]))
+		if size <= 0 || off+size > len(data) {
+			return ""
+		}
+		return utils.UTF16ToString(data[off : off+size])
+	}
+
+	alias := &SAMAlias{Name: str(0x10), Comment: str(0x1c)}
+
+	off := int(binary.LittleEndian.Uint32(data[0x28:])) + SAM_C_HEADER_SIZE
+	count := int(binary.LittleEndian.Uint32(data[0x30:]))
+	for i := 0; i < count && off < len(data); i++ {
+		sid, size, err := ParseSID(data[off:])
+		if err != nil {
+			break
+		}
+		alias.Members = append(alias.Members, sid)
+		off += size
+	}
+
+	return alias, nil
+}
+
+// ParseSAMDomainSID builds the machine SID from the three sub authorities that
+// end the V record of SAM\Domains\Account.
+func ParseSAMDomainSID(data []byte) (string, error) {
+
+	if len(data) < SAM_DOMAIN_SID_BYTES {
+		return "", fmt.Errorf("account domain record too short")
+	}
+
+	tail := data[len(data)-SAM_DOMAIN_SID_BYTES:]
+	return fmt.Sprintf("S-1-5-21-%d-%d-%d",
+		binary.LittleEndian.Uint32(tail), binary.LittleEndian.Uint32(tail[4:]), binary.LittleEndian.Uint32(tail[8:])), nil
+}
+
+// SAMAccountFlags names the account control bits that are set.
+func SAMAccountFlags(flags uint16) string {
+
+	names := make([]string, 0)
+	for _, f := range samAccountFlags {
+		if flags&f.flag != 0 {
+			names = append(names, f.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func decodeSAMAccount(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	if reg.Name == "V" {
+		v, err := ParseSAMAccountV(data)
+		if err != nil {
+			return nil, err
+		}
+		summary := v.Username
+		if v.FullName != "" {
+			summary += " (" + v.FullName + ")"
+		}
+		return &entities.DecodedValue{
+			Summary: summary,
+			Fields:  []*entities.DecodedField{field("Username", v.Username), field("Full Name", v.FullName), field("Comment", v.Comment)},
+		}, nil
+	}
+
+	f, err := ParseSAMAccountF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	rid := strconv.FormatUint(uint64(f.RID), 10)
+	decoded := &entities.DecodedValue{
+		Summary: fmt.Sprintf("RID %s, %s, %d logons", rid, SAMAccountFlags(f.Flags), f.LogonCount),
+		Fields: []*entities.DecodedField{
+			field("RID", rid),
+			field("Flags", SAMAccountFlags(f.Flags)),
+			field("Last Logon", utils.FormatTime(f.LastLogon)),
+			field("Password Last Set", utils.FormatTime(f.PasswordLastSet)),
+			field("Account Expires", utils.FormatTime(f.AccountExpires)),
+			field("Last Failed Logon", utils.FormatTime(f.LastFailedLogon)),
+			field("Failed Logon Count", strconv.FormatUint(uint64(f.FailedCount), 10)),
+			field("Logon Count", strconv.FormatUint(uint64(f.LogonCount), 10)),
+		},
+	}
+
+	for _, e := range []struct {
+		t    time.Time
+		what string
+	}{{f.LastLogon, "Last logon"}, {f.PasswordLastSet, "Password last set"}, {f.LastFailedLogon, "Last failed logon"}} {
+		if e.t.IsZero() {
+			continue
+		}
+		decoded.Events = append(decoded.Events, &entities.TimelineEvent{
+			Time:        e.t,
+			Source:      DECODER_SAM_ACCOUNT,
+			Path:        reg.Path,
+			Name:        reg.Name,
+			Description: fmt.Sprintf("%s of account RID %s", e.what, rid),
+		})
+	}
+
+	return decoded, nil
+}
+
+func decodeSAMAlias(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	alias, err := ParseSAMAlias(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.DecodedValue{
+		Summary: fmt.Sprintf("%s: %s", alias.Name, strings.Join(alias.Members, ", ")),
+		Fields: []*entities.DecodedField{
+			field("Name", alias.Name),
+			field("Comment", alias.Comment),
+			field("Members", strings.Join(alias.Members, ", ")),
+		},
+	}, nil
+}
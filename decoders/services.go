@@ -0,0 +1,228 @@
+package decoders
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+// Service configuration values of the SYSTEM hive, see the CreateService and
+// ChangeServiceConfig2 documentation for their meaning.
+
+const (
+	DECODER_SERVICE_CONFIG    string = "Service Config"
+	DECODER_SERVICE_FAILURE   string = "Service Failure Actions"
+	STR_SERVICE_KEY_PATTERN   string = "HKEY_LOCAL_MACHINE\\SYSTEM\\*\\Services\\*"
+	SERVICE_FAILURE_HEADER    int    = 20
+	SERVICE_FAILURE_ACTION    int    = 8
+	SERVICE_FAILURE_NO_RESET  uint32 = 0xffffffff
+	SERVICE_TYPE_USER_SERVICE uint32 = 0x40
+)
+
+var (
+	serviceStartNames = map[uint32]string{
+		0: "Boot",
+		1: "System",
+		2: "Automatic",
+		3: "Manual",
+		4: "Disabled",
+	}
+
+	serviceErrorControlNames = map[uint32]string{
+		0: "Ignore",
+		1: "Normal",
+		2: "Severe",
+		3: "Critical",
+	}
+
+	serviceTypeFlags = []struct {
+		flag uint32
+		name string
+	}{
+		{0x001, "Kernel driver"},
+		{0x002, "File system driver"},
+		{0x004, "Adapter"},
+		{0x008, "Recognizer driver"},
+		{0x010, "Own process"},
+		{0x020, "Shared process"},
+		{SERVICE_TYPE_USER_SERVICE, "User service"},
+		{0x080, "User service instance"},
+		{0x100, "Interactive"},
+	}
+
+	serviceFailureActionNames = map[uint32]string{
+		0: "None",
+		1: "Restart",
+		2: "Reboot",
+		3: "Run command",
+	}
+)
+
+// ServiceFailureAction is one SC_ACTION of a FailureActions value.
+type ServiceFailureAction struct {
+	Type  uint32
+	Delay time.Duration
+}
+
+// ServiceFailureActions is the SERVICE_FAILURE_ACTIONS structure as the
+// service control manager stores it, with 32 bit placeholders for pointers.
+type ServiceFailureActions struct {
+	ResetPeriod time.Duration
+	NoReset     bool
+	Actions     []*ServiceFailureAction
+}
+
+func init() {
+
+	Register(&Decoder{
+		Name:    DECODER_SERVICE_CONFIG,
+		Matcher: Matcher{Path: STR_SERVICE_KEY_PATTERN, Names: []string{"Start", "Type", "ErrorControl"}, Types: []string{utils.STR_REG_DWORD}},
+		Decode:  decodeServiceConfig,
+	})
+
+	Register(&Decoder{
+		Name:    DECODER_SERVICE_FAILURE,
+		Matcher: Matcher{Path: STR_SERVICE_KEY_PATTERN, Names: []string{"FailureActions"}, Types: []string{utils.STR_REG_BINARY}},
+		Decode:  decodeServiceFailureActions,
+	})
+}
+
+// ServiceStartName names a Start value, delayed marks DelayedAutostart.
+func ServiceStartName(start uint32, delayed bool) string {
+
+	name, ok := serviceStartNames[start]
+	if !ok {
+		return fmt.Sprintf("Unknown (%d)", start)
+	}
+	if start == 2 && delayed {
+		name += " (Delayed)"
+	}
+	return name
+}
+
+func ServiceErrorControlName(errorControl uint32) string {
+
+	if name, ok := serviceErrorControlNames[errorControl]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%d)", errorControl)
+}
+
+// ServiceTypeName names the service type bits that are set.
+func ServiceTypeName(serviceType uint32) string {
+
+	names := make([]string, 0)
+	for _, f := range serviceTypeFlags {
+		if serviceType&f.flag != 0 {
+			names = append(names, f.name)
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("Unknown (0x%x)", serviceType)
+	}
+	return strings.Join(names, ", ")
+}
+
+func ParseServiceFailureActions(data []byte) (*ServiceFailureActions, error) {
+
+	if len(data) < SERVICE_FAILURE_HEADER {
+		return nil, fmt.Errorf("failure actions need %d bytes, got %d", SERVICE_FAILURE_HEADER, len(data))
+	}
+
+	reset := binary.LittleEndian.Uint32(data)
+	count := binary.LittleEndian.Uint32(data[12:])
+	if uint64(count) > uint64((len(data)-SERVICE_FAILURE_HEADER)/SERVICE_FAILURE_ACTION) {
+		return nil, fmt.Errorf("failure actions count %d exceeds data", count)
+	}
+
+	fa := &ServiceFailureActions{
+		ResetPeriod: time.Duration(reset) * time.Second,
+		NoReset:     reset == SERVICE_FAILURE_NO_RESET,
+	}
+
+	for i := 0; i < int(count); i++ {
+		off := SERVICE_FAILURE_HEADER + i*SERVICE_FAILURE_ACTION
+		fa.Actions = append(fa.Actions, &ServiceFailureAction{
+			Type:  binary.LittleEndian.Uint32(data[off:]),
+			Delay: time.Duration(binary.LittleEndian.Uint32(data[off+4:])) * time.Millisecond,
+		})
+	}
+
+	return fa, nil
+}
+
+// String summarises the actions in order, e.g. "Restart after 1m0s, None;
+// reset after 24h0m0s".
+func (fa *ServiceFailureActions) String() string {
+
+	actions := make([]string, 0, len(fa.Actions))
+	for _, a := range fa.Actions {
+		name := serviceFailureActionName(a.Type)
+		if a.Type != 0 {
+			name += " after " + a.Delay.String()
+		}
+		actions = append(actions, name)
+	}
+
+	summary := strings.Join(actions, ", ")
+	if summary == "" {
+		summary = "No actions"
+	}
+	if fa.NoReset {
+		return summary + "; never reset"
+	}
+	return summary + "; reset after " + fa.ResetPeriod.String()
+}
+
+func serviceFailureActionName(action uint32) string {
+
+	if name, ok := serviceFailureActionNames[action]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%d)", action)
+}
+
+func decodeServiceConfig(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	v := binary.LittleEndian.Uint32(data)
+
+	var name string
+	switch reg.Name {
+	case "Start":
+		name = ServiceStartName(v, false)
+	case "Type":
+		name = ServiceTypeName(v)
+	default:
+		name = ServiceErrorControlName(v)
+	}
+
+	return &entities.DecodedValue{
+		Summary: name,
+		Fields:  []*entities.DecodedField{field(reg.Name, name), field("Value", strconv.FormatUint(uint64(v), 10))},
+	}, nil
+}
+
+func decodeServiceFailureActions(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	fa, err := ParseServiceFailureActions(data)
+	if err != nil {
+		return nil, err
+	}
+
+	reset := fa.ResetPeriod.String()
+	if fa.NoReset {
+		reset = "Never"
+	}
+
+	fields := []*entities.DecodedField{field("Reset Period", reset)}
+	for i, a := range fa.Actions {
+		fields = append(fields, field(fmt.Sprintf("Action %d", i+1), fmt.Sprintf("%s, delay %s", serviceFailureActionName(a.Type), a.Delay)))
+	}
+
+	return &entities.DecodedValue{Summary: fa.String(), Fields: fields}, nil
+}
@@ -0,0 +1,47 @@
+package decoders
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseServiceFailureActions(t *testing.T) {
+
+	header := func(reset, count uint32) []byte {
+		return uint32Bytes(reset, 0, 0, count, 0)
+	}
+	actions := uint32Bytes(1, 60000, 0, 0)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		reset   time.Duration
+		actions int
+	}{
+		{"two actions", join(header(86400, 2), actions), 24 * time.Hour, 2},
+		{"no actions", header(0, 0), 0, 0},
+		{"count below the data", join(header(60, 1), actions), time.Minute, 1},
+	}
+
+	for _, tt := range tests {
+		fa, err := ParseServiceFailureActions(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if fa.ResetPeriod != tt.reset || len(fa.Actions) != tt.actions {
+			t.Errorf("%s: reset %s with %d actions, want %s with %d", tt.name, fa.ResetPeriod, len(fa.Actions), tt.reset, tt.actions)
+		}
+	}
+
+	for name, data := range map[string][]byte{
+		"too short":                  make([]byte, SERVICE_FAILURE_HEADER-1),
+		"count beyond data":          join(header(0, 3), actions),
+		"count wrapping 32 bit math": join(header(0, 0x20000000), actions),
+		"count negative as int32":    join(header(0, 0xffffffff), actions),
+	} {
+		if _, err := ParseServiceFailureActions(data); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package decoders
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	DECODER_SHELLBAG string = "ShellBag"
+)
+
+func init() {
+
+	Register(&Decoder{
+		Name:    DECODER_SHELLBAG,
+		Matcher: Matcher{Path: "**\\Shell*\\BagMRU\\**", Types: []string{utils.STR_REG_BINARY}},
+		Decode:  decodeShellBag,
+	})
+}
+
+// IsMRUSlot reports whether a value name is a numbered MRU item slot.
+func IsMRUSlot(name string) bool {
+
+	_, err := strconv.ParseUint(name, 10, 32)
+	return err == nil
+}
+
+func decodeShellBag(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	if !IsMRUSlot(reg.Name) {
+		return nil, fmt.Errorf("not a bag slot")
+	}
+
+	items, err := ParseIDList(data)
+	if err != nil {
+		return nil, err
+	}
+
+	item := items[len(items)-1]
+	decoded := &entities.DecodedValue{
+		Summary: fmt.Sprintf("%s (%s)", IDListPath(items), item.Type),
+		Fields: []*entities.DecodedField{
+			field("Name", IDListPath(items)),
+			field("Item Type", item.Type),
+		},
+	}
+
+	if item.MFTEntry != 0 {
+		decoded.Fields = append(decoded.Fields,
+			field("MFT Entry", strconv.FormatUint(item.MFTEntry, 10)),
+			field("MFT Sequence", strconv.FormatUint(uint64(item.MFTSeq), 10)))
+	}
+	for _, t := range []struct {
+		name  string
+		value string
+	}{{"Modified", utils.FormatTime(item.Modified)}, {"Accessed", utils.FormatTime(item.Accessed)}, {"Created", utils.FormatTime(item.Created)}} {
+		if t.value != "" {
+			decoded.Fields = append(decoded.Fields, field(t.name, t.value))
+		}
+	}
+
+	return decoded, nil
+}
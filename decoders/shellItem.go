@@ -0,0 +1,253 @@
+package decoders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	SHELL_ITEM_ROOT_FOLDER   string = "Root Folder"
+	SHELL_ITEM_VOLUME        string = "Volume"
+	SHELL_ITEM_FOLDER        string = "Folder"
+	SHELL_ITEM_FILE          string = "File"
+	SHELL_ITEM_NETWORK       string = "Network Location"
+	SHELL_ITEM_URI           string = "URI"
+	SHELL_ITEM_CONTROL_PANEL string = "Control Panel Item"
+	SHELL_ITEM_DELEGATE      string = "Delegate Item"
+	SHELL_ITEM_UNKNOWN       string = "Unknown"
+
+	SHELL_ITEM_MAX_IDLIST int = 64
+)
+
+var (
+	beef0004Signature = []byte{0x04, 0x00, 0xef, 0xbe}
+	delegateSignature = []byte("CFSF")
+)
+
+// ShellItem is one decoded entry of a shell item ID list (PIDL), as found in
+// ShellBags, OpenSavePidlMRU and RecentDocs values.
+type ShellItem struct {
+	Type     string
+	Class    byte
+	Name     string
+	MFTEntry uint64
+	MFTSeq   uint16
+	Modified time.Time
+	Accessed time.Time
+	Created  time.Time
+}
+
+// ParseIDList decodes consecutive shell items up to the terminating zero size.
+func ParseIDList(data []byte) ([]*ShellItem, error) {
+
+	items := make([]*ShellItem, 0)
+
+	for off := 0; off+2 <= len(data) && len(items) < SHELL_ITEM_MAX_IDLIST; {
+
+		size := int(binary.LittleEndian.Uint16(data[off:]))
+		if size == 0 {
+			break
+		}
+		if size < 3 || off+size > len(data) {
+			return items, fmt.Errorf("shell item at %d has invalid size %d", off, size)
+		}
+
+		items = append(items, ParseShellItem(data[off:off+size]))
+		off += size
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("empty shell item list")
+	}
+
+	return items, nil
+}
+
+// IDListPath joins the item names of an ID list into a display path.
+func IDListPath(items []*ShellItem) string {
+
+	path := ""
+	for _, item := range items {
+		path = JoinShellPath(path, item.Name)
+	}
+	return path
+}
+
+func JoinShellPath(parent string, name string) string {
+
+	if parent == "" {
+		return name
+	}
+	if strings.HasSuffix(parent, "\\") {
+		return parent + name
+	}
+	return parent + "\\" + name
+}
+
+// ParseShellItem decodes a single shell item including its leading size field.
+func ParseShellItem(data []byte) *ShellItem {
+
+	class := data[2]
+	item := &ShellItem{Class: class, Type: SHELL_ITEM_UNKNOWN, Name: fmt.Sprintf("(unknown shell item 0x%02x)", class)}
+
+	switch {
+
+	case class == 0x1f:
+		item.Type = SHELL_ITEM_ROOT_FOLDER
+		if len(data) >= 20 {
+			item.Name = shellFolderName(data[4:20])
+		}
+
+	case class == 0x2e:
+		item.Type = SHELL_ITEM_VOLUME
+		if len(data) >= 20 {
+			item.Name = shellFolderName(data[4:20])
+		}
+
+	case class&0x70 == 0x20:
+		item.Type = SHELL_ITEM_VOLUME
+		item.Name = cString(data[3:])
+
+	case class&0x70 == 0x30:
+		parseFileEntry(data, item)
+
+	case class&0x70 == 0x40:
+		item.Type = SHELL_ITEM_NETWORK
+		if len(data) > 5 {
+			item.Name = cString(data[5:])
+		}
+
+	case class == 0x61:
+		parseURI(data, item)
+
+	case class == 0x71:
+		item.Type = SHELL_ITEM_CONTROL_PANEL
+		if len(data) >= 30 {
+			item.Name = shellFolderName(data[14:30])
+		}
+
+	case class == 0x74:
+		if len(data) > 14 && bytes.Equal(data[6:10], delegateSignature) {
+			parseFileEntry(data[10:], item)
+			item.Type = SHELL_ITEM_DELEGATE
+		}
+	}
+
+	return item
+}
+
+func parseFileEntry(data []byte, item *ShellItem) {
+
+	item.Type = SHELL_ITEM_FILE
+	if data[2]&0x01 != 0 {
+		item.Type = SHELL_ITEM_FOLDER
+	}
+
+	if len(data) < 14 {
+		return
+	}
+
+	item.Modified = fatTime(data[8:12])
+
+	if data[2]&0x04 != 0 {
+		item.Name = utils.UTF16ToString(data[14:])
+	} else {
+		item.Name = cString(data[14:])
+	}
+
+	ext := bytes.Index(data[14:], beef0004Signature)
+	if ext < 0 {
+		return
+	}
+	ext += 14 - 4
+	if ext < 14 || ext+20 > len(data) {
+		return
+	}
+
+	version := binary.LittleEndian.Uint16(data[ext+2:])
+	item.Created = fatTime(data[ext+8 : ext+12])
+	item.Accessed = fatTime(data[ext+12 : ext+16])
+
+	nameOff := ext + 20
+	if version >= 7 && ext+36 <= len(data) {
+		ref := binary.LittleEndian.Uint64(data[ext+20:])
+		item.MFTEntry = ref & 0x0000ffffffffffff
+		item.MFTSeq = uint16(ref >> 48)
+		nameOff = ext + 38
+		if version >= 8 {
+			nameOff += 4
+		}
+		if version >= 9 {
+			nameOff += 4
+		}
+	}
+
+	if version >= 3 && nameOff < len(data) {
+		if long := utils.UTF16ToString(data[nameOff:]); long != "" {
+			item.Name = long
+		}
+	}
+}
+
+func parseURI(data []byte, item *ShellItem) {
+
+	item.Type = SHELL_ITEM_URI
+	if len(data) < 6 {
+		return
+	}
+
+	flags := data[3]
+	dataSize := int(binary.LittleEndian.Uint16(data[4:]))
+	off := 6 + dataSize
+	if off >= len(data) {
+		return
+	}
+
+	if dataSize >= 16 {
+		item.Accessed, _ = ParseFiletime(data[14:22])
+	}
+
+	if flags&0x80 != 0 {
+		item.Name = utils.UTF16ToString(data[off:])
+	} else {
+		item.Name = cString(data[off:])
+	}
+}
+
+func shellFolderName(data []byte) string {
+
+	guid, err := ParseGUID(data)
+	if err != nil {
+		return ""
+	}
+	if name, ok := ShellFolderName(guid); ok {
+		return name
+	}
+	return guid
+}
+
+// fatTime converts a FAT date (low word) and time (high word) to UTC.
+func fatTime(data []byte) time.Time {
+
+	date := binary.LittleEndian.Uint16(data[0:])
+	tm := binary.LittleEndian.Uint16(data[2:])
+	if date == 0 {
+		return time.Time{}
+	}
+
+	return time.Date(int(date>>9)+1980, time.Month((date>>5)&0x0f), int(date&0x1f),
+		int(tm>>11), int((tm>>5)&0x3f), int(tm&0x1f)*2, 0, time.UTC)
+}
+
+func cString(data []byte) string {
+
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		data = data[:i]
+	}
+	return string(data)
+}
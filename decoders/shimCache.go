@@ -0,0 +1,241 @@
+package decoders
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	DECODER_SHIMCACHE string = "ShimCache"
+
+	SHIMCACHE_VERSION_WIN7  string = "Windows 7"
+	SHIMCACHE_VERSION_WIN8  string = "Windows 8"
+	SHIMCACHE_VERSION_WIN81 string = "Windows 8.1"
+	SHIMCACHE_VERSION_WIN10 string = "Windows 10/11"
+
+	SHIMCACHE_WIN7_MAGIC        uint32 = 0xbadc0fee
+	SHIMCACHE_WIN7_HEADER_SIZE  int    = 0x80
+	SHIMCACHE_WIN7_ENTRY32_SIZE int    = 0x20
+	SHIMCACHE_WIN7_ENTRY64_SIZE int    = 0x30
+	SHIMCACHE_WIN8_HEADER_SIZE  uint32 = 0x80
+	SHIMCACHE_WIN10_HEADER_SIZE uint32 = 0x30
+	SHIMCACHE_WIN10_CREATORS    uint32 = 0x34
+	SHIMCACHE_INSERT_EXECUTED   uint32 = 0x00000002
+)
+
+var (
+	shimCacheWin8Signature  = []byte("00ts")
+	shimCacheWin81Signature = []byte("10ts")
+)
+
+// ShimCacheEntry is one cached executable in AppCompatCache order. HasExecuted
+// is false on Windows 10 and later, which no longer record the flag.
+type ShimCacheEntry struct {
+	Position     int
+	Path         string
+	LastModified time.Time
+	Executed     bool
+	HasExecuted  bool
+	DataSize     uint32
+}
+
+func init() {
+
+	Register(&Decoder{
+		Name:    DECODER_SHIMCACHE,
+		Matcher: Matcher{Path: "**\\Control\\Session Manager\\AppCompatCache", Names: []string{"AppCompatCache"}, Types: []string{utils.STR_REG_BINARY}},
+		Decode:  decodeShimCache,
+	})
+}
+
+// ParseShimCache detects the AppCompatCache layout from its header and returns
+// the Windows version it belongs to with the cached entries.
+func ParseShimCache(data []byte) (string, []*ShimCacheEntry, error) {
+
+	if len(data) < 4 {
+		return "", nil, fmt.Errorf("appcompatcache too short")
+	}
+
+	header := binary.LittleEndian.Uint32(data)
+
+	switch {
+	case header == SHIMCACHE_WIN7_MAGIC:
+		entries, err := parseShimCacheWin7(data)
+		return SHIMCACHE_VERSION_WIN7, entries, err
+
+	case header == SHIMCACHE_WIN8_HEADER_SIZE && hasSignatureAt(data, int(header), shimCacheWin8Signature):
+		entries, err := parseShimCacheSigned(data, int(header), true)
+		return SHIMCACHE_VERSION_WIN8, entries, err
+
+	case header == SHIMCACHE_WIN8_HEADER_SIZE && hasSignatureAt(data, int(header), shimCacheWin81Signature):
+		entries, err := parseShimCacheSigned(data, int(header), true)
+		return SHIMCACHE_VERSION_WIN81, entries, err
+
+	case (header == SHIMCACHE_WIN10_HEADER_SIZE || header == SHIMCACHE_WIN10_CREATORS) && hasSignatureAt(data, int(header), shimCacheWin81Signature):
+		entries, err := parseShimCacheSigned(data, int(header), false)
+		return SHIMCACHE_VERSION_WIN10, entries, err
+	}
+
+	return "", nil, fmt.Errorf("unknown appcompatcache header 0x%08x", header)
+}
+
+func hasSignatureAt(data []byte, off int, sig []byte) bool {
+
+	return off+len(sig) <= len(data) && string(data[off:off+len(sig)]) == string(sig)
+}
+
+// parseShimCacheWin7 reads the fixed size entry table, whose path and data
+// offsets point into the rest of the blob. 64-bit systems pad the path length
+// to eight bytes, which leaves the second dword of the first entry zero.
+func parseShimCacheWin7(data []byte) ([]*ShimCacheEntry, error) {
+
+	if len(data) < SHIMCACHE_WIN7_HEADER_SIZE+8 {
+		return nil, fmt.Errorf("appcompatcache header truncated")
+	}
+
+	count := binary.LittleEndian.Uint32(data[4:])
+	is64 := binary.LittleEndian.Uint32(data[SHIMCACHE_WIN7_HEADER_SIZE+4:]) == 0
+
+	size := SHIMCACHE_WIN7_ENTRY32_SIZE
+	if is64 {
+		size = SHIMCACHE_WIN7_ENTRY64_SIZE
+	}
+
+	// count comes from the blob, never reserve more entries than it can hold
+	capacity := (len(data) - SHIMCACHE_WIN7_HEADER_SIZE) / size
+	if uint64(count) < uint64(capacity) {
+		capacity = int(count)
+	}
+
+	entries := make([]*ShimCacheEntry, 0, capacity)
+	for i := 0; uint64(i) < uint64(count); i++ {
+
+		off := SHIMCACHE_WIN7_HEADER_SIZE + i*size
+		if off+size > len(data) {
+			return entries, fmt.Errorf("appcompatcache entry %d truncated", i)
+		}
+		e := data[off : off+size]
+
+		pathLen := int(binary.LittleEndian.Uint16(e))
+		var pathOff int
+		var rest []byte
+		if is64 {
+			pathOff, rest = int(binary.LittleEndian.Uint64(e[8:])), e[16:]
+		} else {
+			pathOff, rest = int(binary.LittleEndian.Uint32(e[4:])), e[8:]
+		}
+
+		entry := &ShimCacheEntry{Position: i, HasExecuted: true}
+		if pathOff > 0 && pathOff <= len(data)-pathLen {
+			entry.Path = utils.UTF16ToString(data[pathOff : pathOff+pathLen])
+		}
+		entry.LastModified, _ = ParseFiletime(rest[0:8])
+		entry.Executed = binary.LittleEndian.Uint32(rest[8:])&SHIMCACHE_INSERT_EXECUTED != 0
+		entry.DataSize = binary.LittleEndian.Uint32(rest[16:])
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// parseShimCacheSigned reads the variable size "00ts"/"10ts" entries of
+// Windows 8 and later. Windows 8.x entries also carry a package name and the
+// insert flags holding the executed bit.
+func parseShimCacheSigned(data []byte, off int, win8 bool) ([]*ShimCacheEntry, error) {
+
+	entries := make([]*ShimCacheEntry, 0)
+
+	for off+12 <= len(data) {
+
+		sig := string(data[off : off+4])
+		if sig != string(shimCacheWin8Signature) && sig != string(shimCacheWin81Signature) {
+			return entries, fmt.Errorf("unexpected appcompatcache entry signature at 0x%x", off)
+		}
+
+		entrySize := int(binary.LittleEndian.Uint32(data[off+8:]))
+		start := off + 12
+		if entrySize < 0 || entrySize > len(data)-start {
+			return entries, fmt.Errorf("appcompatcache entry %d truncated", len(entries))
+		}
+		e := data[start : start+entrySize]
+		off = start + entrySize
+
+		r := &fieldReader{data: e}
+		entry := &ShimCacheEntry{Position: len(entries)}
+
+		entry.Path = utils.UTF16ToString(r.bytes(int(r.uint16())))
+		if win8 {
+			r.bytes(int(r.uint16()))
+			insertFlags := r.uint32()
+			r.uint32()
+			entry.Executed, entry.HasExecuted = insertFlags&SHIMCACHE_INSERT_EXECUTED != 0, true
+		}
+		entry.LastModified, _ = ParseFiletime(r.bytes(8))
+		entry.DataSize = r.uint32()
+
+		if r.err != nil {
+			return entries, fmt.Errorf("appcompatcache entry %d: %w", entry.Position, r.err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// ShimCacheExecuted renders the executed flag, empty where the version lacks it.
+func ShimCacheExecuted(entry *ShimCacheEntry) string {
+
+	switch {
+	case !entry.HasExecuted:
+		return ""
+	case entry.Executed:
+		return "Yes"
+	default:
+		return "No"
+	}
+}
+
+func decodeShimCache(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	version, entries, err := ParseShimCache(data)
+	if err != nil && len(entries) == 0 {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(entries))
+	decoded := &entities.DecodedValue{Fields: []*entities.DecodedField{field("Version", version)}}
+
+	for _, entry := range entries {
+
+		paths = append(paths, entry.Path)
+
+		detail := []string{entry.Path, utils.FormatTime(entry.LastModified)}
+		if entry.HasExecuted {
+			detail = append(detail, "executed: "+ShimCacheExecuted(entry))
+		}
+		detail = append(detail, "data size: "+strconv.FormatUint(uint64(entry.DataSize), 10))
+		decoded.Fields = append(decoded.Fields, field("#"+strconv.Itoa(entry.Position), strings.Join(detail, ", ")))
+
+		if !entry.LastModified.IsZero() {
+			decoded.Events = append(decoded.Events, &entities.TimelineEvent{
+				Time:        entry.LastModified,
+				Source:      DECODER_SHIMCACHE,
+				Path:        reg.Path,
+				Name:        reg.Name,
+				Description: fmt.Sprintf("Last modified time of %s (cache position %d)", entry.Path, entry.Position),
+			})
+		}
+	}
+
+	decoded.Summary = fmt.Sprintf("%s, %d entries: %s", version, len(entries), strings.Join(paths, "; "))
+
+	return decoded, nil
+}
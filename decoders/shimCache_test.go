@@ -0,0 +1,119 @@
+package decoders
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// The testdata/shimcache_*.bin fixtures hold the same three entries in each
+// AppCompatCache layout, the Windows 8.x ones with a package name on the last.
+var shimCacheFixtureEntries = []ShimCacheEntry{
+	{Position: 0, Path: "C:\\Windows\\System32\\cmd.exe", LastModified: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC), Executed: true, DataSize: 0},
+	{Position: 1, Path: "C:\\Users\\alice\\AppData\\Local\\Temp\\a.exe", LastModified: time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), Executed: false, DataSize: 5},
+	{Position: 2, Path: "\\??\\D:\\Tools\\tool.exe", Executed: true, DataSize: 16},
+}
+
+func TestParseShimCacheFixtures(t *testing.T) {
+
+	tests := []struct {
+		file        string
+		version     string
+		hasExecuted bool
+	}{
+		{"shimcache_win7_x86.bin", SHIMCACHE_VERSION_WIN7, true},
+		{"shimcache_win7_x64.bin", SHIMCACHE_VERSION_WIN7, true},
+		{"shimcache_win8.bin", SHIMCACHE_VERSION_WIN8, true},
+		{"shimcache_win81.bin", SHIMCACHE_VERSION_WIN81, true},
+		{"shimcache_win10.bin", SHIMCACHE_VERSION_WIN10, false},
+		{"shimcache_win10_creators.bin", SHIMCACHE_VERSION_WIN10, false},
+	}
+
+	for _, tt := range tests {
+
+		data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		version, entries, err := ParseShimCache(data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.file, err)
+			continue
+		}
+		if version != tt.version {
+			t.Errorf("%s: version = %q, want %q", tt.file, version, tt.version)
+		}
+		if len(entries) != len(shimCacheFixtureEntries) {
+			t.Errorf("%s: %d entries, want %d", tt.file, len(entries), len(shimCacheFixtureEntries))
+			continue
+		}
+
+		for i, got := range entries {
+			want := shimCacheFixtureEntries[i]
+			want.HasExecuted = tt.hasExecuted
+			if !tt.hasExecuted {
+				want.Executed = false
+			}
+			if got.Position != want.Position || got.Path != want.Path || !got.LastModified.Equal(want.LastModified) ||
+				got.Executed != want.Executed || got.HasExecuted != want.HasExecuted || got.DataSize != want.DataSize {
+				t.Errorf("%s: entry %d = %+v, want %+v", tt.file, i, *got, want)
+			}
+		}
+	}
+}
+
+func TestParseShimCacheRejectsCorruptData(t *testing.T) {
+
+	win10, err := os.ReadFile(filepath.Join("testdata", "shimcache_win10.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	win7, err := os.ReadFile(filepath.Join("testdata", "shimcache_win7_x64.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		wantEntries int
+	}{
+		{"too short", []byte{0x30, 0}, 0},
+		{"unknown header", []byte{1, 2, 3, 4, 5, 6, 7, 8}, 0},
+		{"win10 header without signature", append([]byte{0x30}, make([]byte, 0x40)...), 0},
+		{"win10 last entry truncated", win10[:len(win10)-1], 2},
+		{"win7 entry table truncated", win7[:0x80+0x30+8], 1},
+		{"win7 oversized count", patchedShimCache(win7, 4, 0xffffffff), (len(win7) - SHIMCACHE_WIN7_HEADER_SIZE) / SHIMCACHE_WIN7_ENTRY64_SIZE},
+		{"win10 oversized entry size", patchedShimCache(win10, 0x38, 0xfffffff0), 0},
+	}
+
+	for _, tt := range tests {
+		_, entries, err := ParseShimCache(tt.data)
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+		if len(entries) != tt.wantEntries {
+			t.Errorf("%s: %d entries, want %d", tt.name, len(entries), tt.wantEntries)
+		}
+		if cap(entries) > len(tt.data) {
+			t.Errorf("%s: reserved %d entries for %d bytes", tt.name, cap(entries), len(tt.data))
+		}
+	}
+
+	// a path offset past the blob leaves the path empty
+	_, entries, err := ParseShimCache(patchedShimCache(win7, 0x80+8, 0xfffffff0))
+	if err != nil || len(entries) != 3 || entries[0].Path != "" || entries[1].Path != shimCacheFixtureEntries[1].Path {
+		t.Errorf("win7 path offset out of range: %d entries, %v", len(entries), err)
+	}
+}
+
+// patchedShimCache returns a copy of data with the dword at off set to v.
+func patchedShimCache(data []byte, off int, v uint32) []byte {
+
+	patched := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(patched[off:], v)
+	return patched
+}
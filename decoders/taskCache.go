@@ -0,0 +1,325 @@
+package decoders
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+// Task Scheduler keeps a registry copy of every task under Schedule\TaskCache:
+// Tree mirrors the task folders and points to Tasks\{GUID} through Id, and the
+// Tasks entry holds the binary Actions and DynamicInfo records.
+
+const (
+	DECODER_TASK_ACTIONS      string = "Task Actions"
+	DECODER_TASK_DYNAMIC_INFO string = "Task Dynamic Info"
+	DECODER_TASK_SD           string = "Task Security Descriptor"
+
+	STR_TASKCACHE_TASKS_PATTERN string = "**\\Schedule\\TaskCache\\Tasks\\*"
+	STR_TASKCACHE_TREE_PATTERN  string = "**\\Schedule\\TaskCache\\Tree\\**"
+
+	TASK_ACTION_EXEC        uint16 = 0x6666
+	TASK_ACTION_COM_HANDLER uint16 = 0x7777
+	TASK_ACTION_EMAIL       uint16 = 0x8888
+	TASK_ACTION_MESSAGE_BOX uint16 = 0x9999
+
+	TASK_DYNAMIC_INFO_SIZE       int = 28
+	TASK_DYNAMIC_INFO_SIZE_WIN10 int = 36
+
+	SECURITY_DESCRIPTOR_SIZE int = 20
+)
+
+// TaskAction is one action of an Actions record. Exec actions fill Command,
+// Arguments and WorkingDir, COM handlers CLSID and Data, and the deprecated
+// e-mail and message box actions Data.
+type TaskAction struct {
+	Type       string
+	ID         string
+	Command    string
+	Arguments  string
+	WorkingDir string
+	CLSID      string
+	Data       string
+}
+
+// TaskActions is a parsed Actions record. Context is the principal the
+// actions run as, recorded from version 3 on.
+type TaskActions struct {
+	Version uint16
+	Context string
+	Actions []*TaskAction
+}
+
+type TaskDynamicInfo struct {
+	Created           time.Time
+	LastRun           time.Time
+	LastSuccessfulRun time.Time
+	State             uint32
+	LastResult        uint32
+}
+
+type SecurityDescriptor struct {
+	Owner    string
+	Group    string
+	Control  uint16
+	HasDACL  bool
+	DACLAces int
+}
+
+func init() {
+
+	Register(&Decoder{
+		Name:    DECODER_TASK_ACTIONS,
+		Matcher: Matcher{Path: STR_TASKCACHE_TASKS_PATTERN, Names: []string{"Actions"}, Types: []string{utils.STR_REG_BINARY}},
+		Decode:  decodeTaskActions,
+	})
+
+	Register(&Decoder{
+		Name:    DECODER_TASK_DYNAMIC_INFO,
+		Matcher: Matcher{Path: STR_TASKCACHE_TASKS_PATTERN, Names: []string{"DynamicInfo"}, Types: []string{utils.STR_REG_BINARY}},
+		Decode:  decodeTaskDynamicInfo,
+	})
+
+	Register(&Decoder{
+		Name:    DECODER_TASK_SD,
+		Matcher: Matcher{Path: STR_TASKCACHE_TREE_PATTERN, Names: []string{"SD"}, Types: []string{utils.STR_REG_BINARY}},
+		Decode:  decodeTaskSD,
+	})
+}
+
+func ParseTaskActions(data []byte) (*TaskActions, error) {
+
+	r := &fieldReader{data: data}
+	ta := &TaskActions{Version: r.uint16()}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if ta.Version == 0 || ta.Version > 3 {
+		return nil, fmt.Errorf("unknown task actions version %d", ta.Version)
+	}
+	if ta.Version >= 3 {
+		ta.Context = r.sizedString()
+		if r.err != nil {
+			return nil, r.err
+		}
+	}
+
+	for r.err == nil && r.off+2 <= len(data) {
+
+		a := &TaskAction{}
+		magic := r.uint16()
+		a.ID = r.sizedString()
+
+		switch magic {
+		case TASK_ACTION_EXEC:
+			a.Type = "Exec"
+			a.Command = r.sizedString()
+			a.Arguments = r.sizedString()
+			a.WorkingDir = r.sizedString()
+			// version 3 exec actions may end in a 16 bit flags field
+			if ta.Version >= 3 && r.off+2 <= len(data) && !isTaskActionMagic(binary.LittleEndian.Uint16(data[r.off:])) {
+				r.uint16()
+			}
+		case TASK_ACTION_COM_HANDLER:
+			a.Type = "COM Handler"
+			a.CLSID, _ = ParseGUID(r.bytes(16))
+			a.Data = r.sizedString()
+		case TASK_ACTION_EMAIL:
+			a.Type = "Send Email"
+			fields := make([]string, 0)
+			for _, name := range []string{"From", "To", "Cc", "Bcc", "ReplyTo", "Server", "Subject", "Body"} {
+				if s := r.sizedString(); s != "" {
+					fields = append(fields, name+"="+s)
+				}
+			}
+			for i, n := 0, int(r.uint32()); i < n && r.err == nil; i++ {
+				fields = append(fields, "Attachment="+r.sizedString())
+			}
+			for i, n := 0, int(r.uint32()); i < n && r.err == nil; i++ {
+				fields = append(fields, r.sizedString()+"="+r.sizedString())
+			}
+			a.Data = strings.Join(fields, "; ")
+		case TASK_ACTION_MESSAGE_BOX:
+			a.Type = "Message Box"
+			caption := r.sizedString()
+			a.Data = caption + ": " + r.sizedString()
+		default:
+			return ta, fmt.Errorf("unknown task action 0x%04x at 0x%x", magic, r.off-2)
+		}
+
+		if r.err != nil {
+			return ta, r.err
+		}
+		ta.Actions = append(ta.Actions, a)
+	}
+
+	return ta, nil
+}
+
+func isTaskActionMagic(magic uint16) bool {
+
+	switch magic {
+	case TASK_ACTION_EXEC, TASK_ACTION_COM_HANDLER, TASK_ACTION_EMAIL, TASK_ACTION_MESSAGE_BOX:
+		return true
+	}
+	return false
+}
+
+// String renders an action the way a command line or handler reads.
+func (a *TaskAction) String() string {
+
+	switch {
+	case a.Command != "":
+		s := a.Command
+		if a.Arguments != "" {
+			s += " " + a.Arguments
+		}
+		return s
+	case a.CLSID != "":
+		s := a.CLSID
+		if a.Data != "" {
+			s += " " + a.Data
+		}
+		return s
+	default:
+		return a.Data
+	}
+}
+
+// String joins the actions, e.g. "C:\x.exe -a; {CLSID} data".
+func (ta *TaskActions) String() string {
+
+	actions := make([]string, 0, len(ta.Actions))
+	for _, a := range ta.Actions {
+		actions = append(actions, a.String())
+	}
+	return strings.Join(actions, "; ")
+}
+
+// ParseTaskDynamicInfo reads the 28 byte record, or the 36 byte one Windows
+// 10 and later write with the last successful run appended.
+func ParseTaskDynamicInfo(data []byte) (*TaskDynamicInfo, error) {
+
+	if len(data) != TASK_DYNAMIC_INFO_SIZE && len(data) != TASK_DYNAMIC_INFO_SIZE_WIN10 {
+		return nil, fmt.Errorf("dynamic info needs %d or %d bytes, got %d", TASK_DYNAMIC_INFO_SIZE, TASK_DYNAMIC_INFO_SIZE_WIN10, len(data))
+	}
+
+	info := &TaskDynamicInfo{
+		State:      binary.LittleEndian.Uint32(data[20:]),
+		LastResult: binary.LittleEndian.Uint32(data[24:]),
+	}
+	info.Created, _ = ParseFiletime(data[4:])
+	info.LastRun, _ = ParseFiletime(data[12:])
+	if len(data) == TASK_DYNAMIC_INFO_SIZE_WIN10 {
+		info.LastSuccessfulRun, _ = ParseFiletime(data[28:])
+	}
+
+	return info, nil
+}
+
+// ParseSecurityDescriptor reads the header, owner and group of a self
+// relative security descriptor and counts the ACEs of its DACL.
+func ParseSecurityDescriptor(data []byte) (*SecurityDescriptor, error) {
+
+	if len(data) < SECURITY_DESCRIPTOR_SIZE || data[0] != 1 {
+		return nil, fmt.Errorf("not a self relative security descriptor")
+	}
+
+	sd := &SecurityDescriptor{Control: binary.LittleEndian.Uint16(data[2:])}
+
+	sid := func(off uint32) string {
+		if off == 0 || int(off) >= len(data) {
+			return ""
+		}
+		s, _, err := ParseSID(data[off:])
+		if err != nil {
+			return ""
+		}
+		return s
+	}
+	sd.Owner = sid(binary.LittleEndian.Uint32(data[4:]))
+	sd.Group = sid(binary.LittleEndian.Uint32(data[8:]))
+
+	if dacl := binary.LittleEndian.Uint32(data[16:]); dacl != 0 && int(dacl)+8 <= len(data) {
+		sd.HasDACL = true
+		sd.DACLAces = int(binary.LittleEndian.Uint16(data[dacl+4:]))
+	}
+
+	return sd, nil
+}
+
+func decodeTaskActions(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	ta, err := ParseTaskActions(data)
+	if err != nil && (ta == nil || len(ta.Actions) == 0) {
+		return nil, err
+	}
+
+	fields := []*entities.DecodedField{field("Version", fmt.Sprint(ta.Version)), field("Context", ta.Context)}
+	for i, a := range ta.Actions {
+		fields = append(fields, field(fmt.Sprintf("Action %d (%s)", i+1, a.Type), a.String()))
+		if a.WorkingDir != "" {
+			fields = append(fields, field(fmt.Sprintf("Action %d Working Directory", i+1), a.WorkingDir))
+		}
+	}
+
+	return &entities.DecodedValue{Summary: ta.String(), Fields: fields}, nil
+}
+
+func decodeTaskDynamicInfo(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	info, err := ParseTaskDynamicInfo(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := &entities.DecodedValue{
+		Summary: fmt.Sprintf("created %s, last run %s", utils.FormatTime(info.Created), utils.FormatTime(info.LastRun)),
+		Fields: []*entities.DecodedField{
+			field("Created", utils.FormatTime(info.Created)),
+			field("Last Run", utils.FormatTime(info.LastRun)),
+			field("Last Successful Run", utils.FormatTime(info.LastSuccessfulRun)),
+			field("Last Result", fmt.Sprintf("0x%08x", info.LastResult)),
+		},
+	}
+
+	for _, e := range []struct {
+		t    time.Time
+		what string
+	}{{info.Created, "Task registered"}, {info.LastRun, "Task last run"}, {info.LastSuccessfulRun, "Task last successful run"}} {
+		if e.t.IsZero() {
+			continue
+		}
+		decoded.Events = append(decoded.Events, &entities.TimelineEvent{
+			Time:        e.t,
+			Source:      DECODER_TASK_DYNAMIC_INFO,
+			Path:        reg.Path,
+			Name:        reg.Name,
+			Description: e.what,
+		})
+	}
+
+	return decoded, nil
+}
+
+func decodeTaskSD(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	sd, err := ParseSecurityDescriptor(data)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := fmt.Sprintf("Owner %s, group %s", sd.Owner, sd.Group)
+	if sd.HasDACL {
+		summary += fmt.Sprintf(", %d ACEs", sd.DACLAces)
+	}
+
+	return &entities.DecodedValue{
+		Summary: summary,
+		Fields:  []*entities.DecodedField{field("Owner", sd.Owner), field("Group", sd.Group), field("DACL ACEs", fmt.Sprint(sd.DACLAces))},
+	}, nil
+}
@@ -0,0 +1,226 @@
+package decoders
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+const STR_TEST_TASK_CLSID string = "{0F87369F-A4E5-4CFC-BD3E-73E6154572DD}"
+
+func TestParseTaskActions(t *testing.T) {
+
+	clsid, _ := hex.DecodeString("9f36870fe5a4fc4cbd3e73e6154572dd")
+
+	exec := taskRecord(uint16(TASK_ACTION_EXEC), "exec", "C:\\Windows\\System32\\cmd.exe", "/c whoami", "C:\\Temp")
+	com := append(taskRecord(uint16(TASK_ACTION_COM_HANDLER), "com"), clsid...)
+	com = append(com, taskRecord("data")...)
+	email := taskRecord(uint16(TASK_ACTION_EMAIL), "mail", "a@example.com", "b@example.com", "", "", "", "smtp.example.com", "Hi", "")
+	email = append(email, uint32Bytes(1)...)
+	email = append(email, taskRecord("C:\\report.txt")...)
+	email = append(email, uint32Bytes(1)...)
+	email = append(email, taskRecord("X-Tag", "1")...)
+	msgbox := taskRecord(uint16(TASK_ACTION_MESSAGE_BOX), "msg", "Caption", "Body")
+
+	tests := []struct {
+		name    string
+		data    []byte
+		version uint16
+		context string
+		want    []TaskAction
+	}{
+		{"v1 exec", join(taskRecord(uint16(1)), exec), 1, "", []TaskAction{
+			{Type: "Exec", ID: "exec", Command: "C:\\Windows\\System32\\cmd.exe", Arguments: "/c whoami", WorkingDir: "C:\\Temp"},
+		}},
+		{"v1 all action types", join(taskRecord(uint16(1)), exec, com, email, msgbox), 1, "", []TaskAction{
+			{Type: "Exec", ID: "exec", Command: "C:\\Windows\\System32\\cmd.exe", Arguments: "/c whoami", WorkingDir: "C:\\Temp"},
+			{Type: "COM Handler", ID: "com", CLSID: STR_TEST_TASK_CLSID, Data: "data"},
+			{Type: "Send Email", ID: "mail", Data: "From=a@example.com; To=b@example.com; Server=smtp.example.com; Subject=Hi; Attachment=C:\\report.txt; X-Tag=1"},
+			{Type: "Message Box", ID: "msg", Data: "Caption: Body"},
+		}},
+		{"v3 exec without flags", join(taskRecord(uint16(3), "Author"), exec, com), 3, "Author", []TaskAction{
+			{Type: "Exec", ID: "exec", Command: "C:\\Windows\\System32\\cmd.exe", Arguments: "/c whoami", WorkingDir: "C:\\Temp"},
+			{Type: "COM Handler", ID: "com", CLSID: STR_TEST_TASK_CLSID, Data: "data"},
+		}},
+		{"v3 exec with flags", join(taskRecord(uint16(3), "Author"), exec, taskRecord(uint16(1)), msgbox), 3, "Author", []TaskAction{
+			{Type: "Exec", ID: "exec", Command: "C:\\Windows\\System32\\cmd.exe", Arguments: "/c whoami", WorkingDir: "C:\\Temp"},
+			{Type: "Message Box", ID: "msg", Data: "Caption: Body"},
+		}},
+		{"v3 exec with trailing flags", join(taskRecord(uint16(3), "LocalSystem"), exec, taskRecord(uint16(0))), 3, "LocalSystem", []TaskAction{
+			{Type: "Exec", ID: "exec", Command: "C:\\Windows\\System32\\cmd.exe", Arguments: "/c whoami", WorkingDir: "C:\\Temp"},
+		}},
+	}
+
+	for _, tt := range tests {
+
+		ta, err := ParseTaskActions(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if ta.Version != tt.version || ta.Context != tt.context {
+			t.Errorf("%s: version %d context %q, want %d %q", tt.name, ta.Version, ta.Context, tt.version, tt.context)
+		}
+		if len(ta.Actions) != len(tt.want) {
+			t.Errorf("%s: %d actions, want %d", tt.name, len(ta.Actions), len(tt.want))
+			continue
+		}
+		for i, a := range ta.Actions {
+			if *a != tt.want[i] {
+				t.Errorf("%s: action %d = %+v, want %+v", tt.name, i, *a, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestParseTaskActionsRejectsCorruptData(t *testing.T) {
+
+	exec := taskRecord(uint16(TASK_ACTION_EXEC), "exec", "C:\\x.exe", "", "")
+
+	tests := []struct {
+		name        string
+		data        []byte
+		wantActions int
+	}{
+		{"empty", nil, 0},
+		{"version 0", join(taskRecord(uint16(0)), exec), 0},
+		{"version 4", join(taskRecord(uint16(4)), exec), 0},
+		{"unknown action", join(taskRecord(uint16(1)), exec, taskRecord(uint16(0x1234), "x")), 1},
+		{"string beyond record", join(taskRecord(uint16(1)), exec, taskRecord(uint16(TASK_ACTION_EXEC)), uint32Bytes(0x1000)), 1},
+		{"truncated guid", join(taskRecord(uint16(1), uint16(TASK_ACTION_COM_HANDLER), "com"), []byte{1, 2, 3}), 0},
+		{"v3 context truncated", join(taskRecord(uint16(3)), uint32Bytes(10), []byte{'A', 0}), 0},
+	}
+
+	for _, tt := range tests {
+		ta, err := ParseTaskActions(tt.data)
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+		got := 0
+		if ta != nil {
+			got = len(ta.Actions)
+		}
+		if got != tt.wantActions {
+			t.Errorf("%s: %d actions, want %d", tt.name, got, tt.wantActions)
+		}
+	}
+}
+
+func TestParseTaskDynamicInfo(t *testing.T) {
+
+	created := time.Date(2022, 5, 6, 7, 8, 9, 0, time.UTC)
+	lastRun := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	lastSuccess := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	info := join(uint32Bytes(3), filetimeBytes(created), filetimeBytes(lastRun), uint32Bytes(1, 0x8004131f))
+
+	tests := []struct {
+		name        string
+		data        []byte
+		lastSuccess time.Time
+	}{
+		{"28 bytes", info, time.Time{}},
+		{"36 bytes", join(info, filetimeBytes(lastSuccess)), lastSuccess},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTaskDynamicInfo(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !got.Created.Equal(created) || !got.LastRun.Equal(lastRun) || !got.LastSuccessfulRun.Equal(tt.lastSuccess) ||
+			got.State != 1 || got.LastResult != 0x8004131f {
+			t.Errorf("%s: %+v", tt.name, *got)
+		}
+	}
+
+	for _, size := range []int{0, 27, 32, 40} {
+		if _, err := ParseTaskDynamicInfo(make([]byte, size)); err == nil {
+			t.Errorf("%d bytes: no error", size)
+		}
+	}
+}
+
+func TestParseSecurityDescriptor(t *testing.T) {
+
+	owner := sidBytes(5, 18)
+	group := sidBytes(5, 32, 544)
+	dacl := []byte{2, 0, 8, 0, 3, 0, 0, 0}
+	header := func(control uint16, ownerOff, groupOff, daclOff uint32) []byte {
+		return join([]byte{1, 0}, taskRecord(control), uint32Bytes(ownerOff, groupOff, 0, daclOff))
+	}
+	ownerOff := uint32(SECURITY_DESCRIPTOR_SIZE)
+	groupOff := ownerOff + uint32(len(owner))
+	daclOff := groupOff + uint32(len(group))
+
+	tests := []struct {
+		name string
+		data []byte
+		want SecurityDescriptor
+	}{
+		{"owner, group and dacl", join(header(0x8004, ownerOff, groupOff, daclOff), owner, group, dacl),
+			SecurityDescriptor{Owner: "S-1-5-18", Group: "S-1-5-32-544", Control: 0x8004, HasDACL: true, DACLAces: 3}},
+		{"no dacl", join(header(0x8000, ownerOff, groupOff, 0), owner, group),
+			SecurityDescriptor{Owner: "S-1-5-18", Group: "S-1-5-32-544", Control: 0x8000}},
+		{"offsets out of range", header(0x8004, 0x1000, 0x2000, 0x3000),
+			SecurityDescriptor{Control: 0x8004}},
+		{"dacl header truncated", join(header(0x8004, ownerOff, 0, groupOff), owner, dacl[:4]),
+			SecurityDescriptor{Owner: "S-1-5-18", Control: 0x8004}},
+	}
+
+	for _, tt := range tests {
+		sd, err := ParseSecurityDescriptor(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if *sd != tt.want {
+			t.Errorf("%s: %+v, want %+v", tt.name, *sd, tt.want)
+		}
+	}
+
+	for name, data := range map[string][]byte{
+		"too short":  make([]byte, SECURITY_DESCRIPTOR_SIZE-1),
+		"revision 2": join([]byte{2}, make([]byte, SECURITY_DESCRIPTOR_SIZE)),
+		"empty":      nil,
+	} {
+		if _, err := ParseSecurityDescriptor(data); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+// taskRecord lays out fields the way Actions records store them: uint16
+// and uint32 fields little endian, strings as UTF-16 preceded by their byte
+// length.
+func taskRecord(fields ...any) []byte {
+
+	data := make([]byte, 0)
+	for _, f := range fields {
+		switch v := f.(type) {
+		case uint16:
+			data = binary.LittleEndian.AppendUint16(data, v)
+		case uint32:
+			data = binary.LittleEndian.AppendUint32(data, v)
+		case string:
+			units := utf16.Encode([]rune(v))
+			data = binary.LittleEndian.AppendUint32(data, uint32(2*len(units)))
+			for _, u := range units {
+				data = binary.LittleEndian.AppendUint16(data, u)
+			}
+		}
+	}
+	return data
+}
+
+func join(parts ...[]byte) []byte {
+
+	data := make([]byte, 0)
+	for _, p := range parts {
+		data = append(data, p...)
+	}
+	return data
+}
@@ -0,0 +1,107 @@
+package decoders
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	DECODER_USERASSIST string = "UserAssist"
+
+	USERASSIST_V5_SIZE int = 72
+	USERASSIST_V3_SIZE int = 16
+)
+
+// UserAssistEntry is one decoded value below UserAssist\{GUID}\Count.
+type UserAssistEntry struct {
+	Name       string
+	RunCount   uint32
+	FocusCount uint32
+	FocusTime  time.Duration
+	LastRun    time.Time
+}
+
+func init() {
+
+	Register(&Decoder{
+		Name:    DECODER_USERASSIST,
+		Matcher: Matcher{Path: "**\\Software\\Microsoft\\Windows\\CurrentVersion\\Explorer\\UserAssist\\*\\Count", Types: []string{utils.STR_REG_BINARY}},
+		Decode:  decodeUserAssist,
+	})
+}
+
+func Rot13(s string) string {
+
+	b := []byte(s)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z':
+			b[i] = 'a' + (c-'a'+13)%26
+		case c >= 'A' && c <= 'Z':
+			b[i] = 'A' + (c-'A'+13)%26
+		}
+	}
+	return string(b)
+}
+
+// ParseUserAssist decodes the ROT13 value name and the version 5 (Windows 7+)
+// or version 3 (XP) statistics blob.
+func ParseUserAssist(name string, data []byte) (*UserAssistEntry, error) {
+
+	entry := &UserAssistEntry{Name: ExpandKnownFolderPrefix(Rot13(name))}
+
+	switch {
+	case len(data) >= USERASSIST_V5_SIZE:
+		entry.RunCount = binary.LittleEndian.Uint32(data[4:])
+		entry.FocusCount = binary.LittleEndian.Uint32(data[8:])
+		entry.FocusTime = time.Duration(binary.LittleEndian.Uint32(data[12:])) * time.Millisecond
+		entry.LastRun, _ = ParseFiletime(data[60:68])
+	case len(data) == USERASSIST_V3_SIZE:
+		entry.RunCount = binary.LittleEndian.Uint32(data[4:])
+		if entry.RunCount >= 5 {
+			entry.RunCount -= 5
+		}
+		entry.LastRun, _ = ParseFiletime(data[8:16])
+	default:
+		return nil, fmt.Errorf("unknown userassist data size %d", len(data))
+	}
+
+	return entry, nil
+}
+
+func decodeUserAssist(reg *entities.Registry, data []byte) (*entities.DecodedValue, error) {
+
+	entry, err := ParseUserAssist(reg.Name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := &entities.DecodedValue{
+		Summary: fmt.Sprintf("%s, run %d times", entry.Name, entry.RunCount),
+		Fields: []*entities.DecodedField{
+			field("Name", entry.Name),
+			field("Run Count", strconv.FormatUint(uint64(entry.RunCount), 10)),
+			field("Focus Count", strconv.FormatUint(uint64(entry.FocusCount), 10)),
+			field("Focus Time", entry.FocusTime.String()),
+			field("Last Run", utils.FormatTime(entry.LastRun)),
+		},
+	}
+
+	if !entry.LastRun.IsZero() {
+		decoded.Summary += ", last " + utils.FormatTime(entry.LastRun)
+		decoded.Events = append(decoded.Events, &entities.TimelineEvent{
+			Time:        entry.LastRun,
+			Source:      DECODER_USERASSIST,
+			Path:        reg.Path,
+			Name:        reg.Name,
+			Description: fmt.Sprintf("Last run of %s (run count %d)", entry.Name, entry.RunCount),
+		})
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,8 @@
+package entities
+
+// PathAlias maps a canonical key path to a virtual path that resolves to the
+// same key, e.g. ControlSet001 and CurrentControlSet.
+type PathAlias struct {
+	Canonical string
+	Alias     string
+}
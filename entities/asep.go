@@ -0,0 +1,11 @@
+package entities
+
+// AsepLocation is one autostart extensibility point of the persistence
+// catalogue. Path is a utils.MatchPathPattern pattern, Values lists value name
+// wildcards where "" is the default value and an empty list means every value.
+type AsepLocation struct {
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Path     string   `json:"path"`
+	Values   []string `json:"values,omitempty"`
+}
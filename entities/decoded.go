@@ -0,0 +1,15 @@
+package entities
+
+// DecodedValue is the structured interpretation of a value's raw data produced
+// by a decoder, with a one-line summary used for display and search.
+type DecodedValue struct {
+	Decoder string
+	Summary string
+	Fields  []*DecodedField
+	Events  []*TimelineEvent
+}
+
+type DecodedField struct {
+	Name  string
+	Value string
+}
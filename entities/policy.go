@@ -0,0 +1,53 @@
+package entities
+
+// Policy is a single Group Policy setting loaded from an ADMX file with its
+// strings already resolved from the matching ADML file.
+type Policy struct {
+	Name          string
+	Class         string
+	DisplayName   string
+	Category      string
+	ExplainText   string
+	Key           string
+	ValueName     string
+	EnabledValue  *PolicyValue
+	DisabledValue *PolicyValue
+	EnabledList   []*PolicyListItem
+	DisabledList  []*PolicyListItem
+	Elements      []*PolicyElement
+}
+
+type PolicyValue struct {
+	Kind string
+	Data string
+}
+
+type PolicyListItem struct {
+	Key       string
+	ValueName string
+	Value     *PolicyValue
+}
+
+type PolicyElement struct {
+	Kind       string
+	ID         string
+	Key        string
+	ValueName  string
+	Items      []*PolicyEnumItem
+	TrueValue  *PolicyValue
+	FalseValue *PolicyValue
+}
+
+type PolicyEnumItem struct {
+	DisplayName string
+	Value       *PolicyValue
+}
+
+// PolicyAnnotation describes which policy setting wrote a registry value and
+// what the current data means for that setting.
+type PolicyAnnotation struct {
+	DisplayName string
+	Category    string
+	ExplainText string
+	Meaning     string
+}
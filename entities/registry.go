@@ -1,8 +1,19 @@
 package entities
 
+import "time"
+
 type Registry struct {
-	Path  string
-	Name  string
-	Type  string
-	Value string
+	Path      string
+	Name      string
+	Type      string
+	Value     string
+	Aliases   []string
+	Origin    string
+	LastWrite time.Time
+	Policy    *PolicyAnnotation
+	Decoded   *DecodedValue
+
+	// Strings holds the strings of a REG_MULTI_SZ value, which Value joins
+	// with ", " for display.
+	Strings []string
 }
@@ -0,0 +1,13 @@
+package entities
+
+// Report is a derived table built from registry entities, such as the
+// ShellBags folder list or the services inventory.
+type Report struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+
+	// RangeColumns name the columns holding number lists such as
+	// "80,443,1000-2000", which a numeric filter term matches by value.
+	RangeColumns []string
+}
@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+// TimelineEvent is a timestamped fact recovered from registry data, such as a
+// program execution or a device connection.
+type TimelineEvent struct {
+	Time        time.Time
+	Source      string
+	Path        string
+	Name        string
+	Description string
+}
@@ -21,15 +21,27 @@ const (
 	DEBOUNCE_INTERVAL time.Duration = 250 * time.Millisecond
 	UPDATE_INTERVAL   time.Duration = 500 * time.Millisecond
 
-	COL_TITLE_PATH  string = "Path"
-	COL_TITLE_NAME  string = "Name"
-	COL_TITLE_TYPE  string = "Type"
-	COL_TITLE_VALUE string = "Value"
-
-	COL_WIDTH_PATH  float32 = 0.4
-	COL_WIDTH_NAME  float32 = 0.1
-	COL_WIDTH_TYPE  float32 = 0.1
-	COL_WIDTH_VALUE float32 = 0.4
+	COL_TITLE_PATH     string = "Path"
+	COL_TITLE_NAME     string = "Name"
+	COL_TITLE_TYPE     string = "Type"
+	COL_TITLE_VALUE    string = "Value"
+	COL_TITLE_EXPANDED string = "Expanded"
+	COL_TITLE_DECODED  string = "Decoded"
+	COL_TITLE_POLICY   string = "Policy"
+	COL_TITLE_ALIAS    string = "Alias"
+	COL_TITLE_ORIGIN   string = "Origin"
+
+	COL_WIDTH_PATH     float32 = 0.20
+	COL_WIDTH_NAME     float32 = 0.09
+	COL_WIDTH_TYPE     float32 = 0.07
+	COL_WIDTH_VALUE    float32 = 0.14
+	COL_WIDTH_EXPANDED float32 = 0.12
+	COL_WIDTH_DECODED  float32 = 0.12
+	COL_WIDTH_POLICY   float32 = 0.09
+	COL_WIDTH_ALIAS    float32 = 0.09
+	COL_WIDTH_ORIGIN   float32 = 0.08
+
+	EXPORT_FILTER string = "CSV Files (*.csv)|*.csv"
 )
 
 type AppWindow struct {
@@ -63,8 +75,13 @@ type AppWindow struct {
 	keyComboBox  *walk.ComboBox
 	typeComboBox *walk.ComboBox
 
-	regKeyModel  *[]string
-	regTypeModel *[]string
+	sidComboBox *walk.ComboBox
+
+	regBackAction *walk.Action
+
+	regKeyModel     *[]string
+	regTypeModel    *[]string
+	sidDisplayModel *[]string
 
 	resultTable   *walk.TableView
 	regTableModel *models.RegistryTableModel
@@ -84,6 +101,7 @@ func NewAppWindow(usecase usecases.RegistryUsecase) (*AppWindow, error) {
 		filterTypeEnabled: false,
 		regKeyModel:       models.NewRegistryKeyModel(),
 		regTypeModel:      models.NewRegistryTypeModel(),
+		sidDisplayModel:   models.NewSidDisplayModel(),
 	}
 
 	var icon, _ = walk.NewIconFromResourceId(2)
@@ -99,6 +117,140 @@ func NewAppWindow(usecase usecases.RegistryUsecase) (*AppWindow, error) {
 			go app.handleOnSizeChanged()
 		},
 
+		MenuItems: []MenuItem{
+			Menu{
+				Text: "&File",
+				Items: []MenuItem{
+					Action{
+						Text: "&Export Results...",
+						OnTriggered: func() {
+							app.handleOnExportResults()
+						},
+					},
+					Action{
+						Text: "Export &Timeline...",
+						OnTriggered: func() {
+							app.handleOnExportTimeline()
+						},
+					},
+				},
+			},
+			Menu{
+				Text: "&Reports",
+				Items: []MenuItem{
+					Action{
+						Text: "&ShellBags",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.ShellBagsReport)
+						},
+					},
+					Action{
+						Text: "S&himCache",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.ShimCacheReport)
+						},
+					},
+					Action{
+						Text: "&Amcache",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.AmcacheReport)
+						},
+					},
+					Action{
+						Text: "&MRU Lists",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.MRUReport)
+						},
+					},
+					Action{
+						Text: "&BAM/DAM",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.BAMReport)
+						},
+					},
+					Action{
+						Text: "&Local Accounts",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.SAMReport)
+						},
+					},
+					Action{
+						Text: "&Persistence",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.PersistenceReport)
+						},
+					},
+					Action{
+						Text: "S&ervices",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.ServicesReport)
+						},
+					},
+					Action{
+						Text: "&Installed Software",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.UninstallReport)
+						},
+					},
+					Action{
+						Text: "&USB Devices",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.USBReport)
+						},
+					},
+					Action{
+						Text: "&Firewall Rules",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.FirewallReport)
+						},
+					},
+					Action{
+						Text: "Scheduled &Tasks",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.TaskCacheReport)
+						},
+					},
+					Action{
+						Text: "&Environment",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.EnvironmentReport)
+						},
+					},
+					Separator{},
+					Action{
+						Text: "File &Association...",
+						OnTriggered: func() {
+							app.showQueryReport(usecases.STR_REPORT_ASSOCIATION, ".txt or ProgID", app.usecase.ResolveAssociation)
+						},
+					},
+					Action{
+						Text: "Association &Overrides",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.AssociationReport)
+						},
+					},
+					Action{
+						Text: "&COM Object...",
+						OnTriggered: func() {
+							app.showQueryReport(usecases.STR_REPORT_COM_OBJECT, "CLSID or ProgID", app.usecase.InspectCOMObject)
+						},
+					},
+					Action{
+						Text: "COM &Hijacks",
+						OnTriggered: func() {
+							app.handleOnReport(app.usecase.COMHijackReport)
+						},
+					},
+					Separator{},
+					Action{
+						AssignTo:  &app.regBackAction,
+						Text:      "Include &RegBack Copies",
+						Checkable: true,
+					},
+				},
+			},
+		},
+
 		Children: []Widget{
 
 			LineEdit{
@@ -121,12 +273,15 @@ func NewAppWindow(usecase usecases.RegistryUsecase) (*AppWindow, error) {
 					},
 					ComboBox{
 						AssignTo:     &app.keyComboBox,
-						Editable:     false,
+						Editable:     true,
 						Model:        *app.regKeyModel,
 						CurrentIndex: 0,
 						OnCurrentIndexChanged: func() {
 							app.onFilterKeyChanged()
 						},
+						OnEditingFinished: func() {
+							app.onFilterKeyChanged()
+						},
 					},
 					CheckBox{
 						AssignTo:       &app.typeCheckBox,
@@ -145,6 +300,15 @@ func NewAppWindow(usecase usecases.RegistryUsecase) (*AppWindow, error) {
 							app.onFilterTypeChanged()
 						},
 					},
+					ComboBox{
+						AssignTo:     &app.sidComboBox,
+						Editable:     false,
+						Model:        *app.sidDisplayModel,
+						CurrentIndex: 0,
+						OnCurrentIndexChanged: func() {
+							app.resultTable.Invalidate()
+						},
+					},
 				},
 			},
 
@@ -156,6 +320,11 @@ func NewAppWindow(usecase usecases.RegistryUsecase) (*AppWindow, error) {
 					{Name: COL_TITLE_NAME, Title: COL_TITLE_NAME, Width: int(COL_WIDTH_NAME * float32(APP_WIDTH))},
 					{Name: COL_TITLE_TYPE, Title: COL_TITLE_TYPE, Width: int(COL_WIDTH_TYPE * float32(APP_WIDTH))},
 					{Name: COL_TITLE_VALUE, Title: COL_TITLE_VALUE, Width: int(COL_WIDTH_VALUE * float32(APP_WIDTH))},
+					{Name: COL_TITLE_EXPANDED, Title: COL_TITLE_EXPANDED, Width: int(COL_WIDTH_EXPANDED * float32(APP_WIDTH))},
+					{Name: COL_TITLE_DECODED, Title: COL_TITLE_DECODED, Width: int(COL_WIDTH_DECODED * float32(APP_WIDTH))},
+					{Name: COL_TITLE_POLICY, Title: COL_TITLE_POLICY, Width: int(COL_WIDTH_POLICY * float32(APP_WIDTH))},
+					{Name: COL_TITLE_ALIAS, Title: COL_TITLE_ALIAS, Width: int(COL_WIDTH_ALIAS * float32(APP_WIDTH))},
+					{Name: COL_TITLE_ORIGIN, Title: COL_TITLE_ORIGIN, Width: int(COL_WIDTH_ORIGIN * float32(APP_WIDTH))},
 				},
 				Model: app.regTableModel,
 				OnItemActivated: func() {
@@ -171,6 +340,17 @@ func NewAppWindow(usecase usecases.RegistryUsecase) (*AppWindow, error) {
 
 	_ = app.SetIcon(icon)
 
+	app.regTableModel.DisplayPath = func(reg *entities.Registry) string {
+		if !app.filterKeyEnabled {
+			return reg.Path
+		}
+		return app.usecase.PathUnder(reg, app.keyComboBox.Text())
+	}
+	app.regTableModel.DisplayText = func(s string) string {
+		return app.usecase.AnnotateGUIDs(app.usecase.DisplaySIDs(s, app.sidComboBox.Text()))
+	}
+	app.regTableModel.DisplayExpanded = app.usecase.ExpandValue
+
 	go app.streamingRegistry()
 
 	go app.processingShowResult()
@@ -354,6 +534,67 @@ func (app *AppWindow) handleOnItemActivated() {
 
 }
 
+func (app *AppWindow) handleOnExportResults() {
+
+	dlg := &walk.FileDialog{Title: "Export Results", Filter: EXPORT_FILTER}
+
+	accepted, err := dlg.ShowSave(app)
+	if err != nil || !accepted {
+		return
+	}
+
+	app.showedResultMu.Lock()
+	showedCopy := make([]*entities.Registry, len(app.showedResult))
+	copy(showedCopy, app.showedResult)
+	app.showedResultMu.Unlock()
+
+	if err := app.usecase.ExportRegistry(dlg.FilePath, showedCopy); err != nil {
+		walk.MsgBox(app, "Export Results", err.Error(), walk.MsgBoxIconError)
+	}
+}
+
+func (app *AppWindow) handleOnExportTimeline() {
+
+	dlg := &walk.FileDialog{Title: "Export Timeline", Filter: EXPORT_FILTER}
+
+	accepted, err := dlg.ShowSave(app)
+	if err != nil || !accepted {
+		return
+	}
+
+	app.collectedResultMu.Lock()
+	collectedCopy := make([]*entities.Registry, len(app.collectedResult))
+	copy(collectedCopy, app.collectedResult)
+	app.collectedResultMu.Unlock()
+
+	if err := app.usecase.ExportTimeline(dlg.FilePath, app.usecase.Timeline(collectedCopy)); err != nil {
+		walk.MsgBox(app, "Export Timeline", err.Error(), walk.MsgBoxIconError)
+	}
+}
+
+// handleOnReport builds a report from everything collected so far and shows it.
+func (app *AppWindow) handleOnReport(build func(regs []*entities.Registry) *entities.Report) {
+
+	app.showReport(build(app.reportSnapshot()))
+}
+
+// reportSnapshot is what reports are built from: everything collected so far,
+// without the RegBack copies of an image unless they were asked for.
+func (app *AppWindow) reportSnapshot() []*entities.Registry {
+
+	return app.usecase.ReportEntities(app.collectedSnapshot(), app.regBackAction.Checked())
+}
+
+func (app *AppWindow) collectedSnapshot() []*entities.Registry {
+
+	app.collectedResultMu.Lock()
+	defer app.collectedResultMu.Unlock()
+
+	collectedCopy := make([]*entities.Registry, len(app.collectedResult))
+	copy(collectedCopy, app.collectedResult)
+	return collectedCopy
+}
+
 func (app *AppWindow) handleOnSizeChanged() {
 
 	app.Synchronize(func() {
@@ -361,6 +602,11 @@ func (app *AppWindow) handleOnSizeChanged() {
 		go app.resultTable.Columns().ByName(COL_TITLE_NAME).SetWidth(int(float32(app.Width()) * (COL_WIDTH_NAME)))
 		go app.resultTable.Columns().ByName(COL_TITLE_TYPE).SetWidth(int(float32(app.Width()) * (COL_WIDTH_TYPE)))
 		go app.resultTable.Columns().ByName(COL_TITLE_VALUE).SetWidth(int(float32(app.Width()) * (COL_WIDTH_VALUE)))
+		go app.resultTable.Columns().ByName(COL_TITLE_EXPANDED).SetWidth(int(float32(app.Width()) * (COL_WIDTH_EXPANDED)))
+		go app.resultTable.Columns().ByName(COL_TITLE_DECODED).SetWidth(int(float32(app.Width()) * (COL_WIDTH_DECODED)))
+		go app.resultTable.Columns().ByName(COL_TITLE_POLICY).SetWidth(int(float32(app.Width()) * (COL_WIDTH_POLICY)))
+		go app.resultTable.Columns().ByName(COL_TITLE_ALIAS).SetWidth(int(float32(app.Width()) * (COL_WIDTH_ALIAS)))
+		go app.resultTable.Columns().ByName(COL_TITLE_ORIGIN).SetWidth(int(float32(app.Width()) * (COL_WIDTH_ORIGIN)))
 	})
 
 }
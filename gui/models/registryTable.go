@@ -1,6 +1,8 @@
 package models
 
 import (
+	"strings"
+
 	"github.com/0736b/registry-finder-gui/entities"
 	"github.com/lxn/walk"
 )
@@ -8,6 +10,17 @@ import (
 type RegistryTableModel struct {
 	walk.TableModelBase
 	Items []*entities.Registry
+
+	// DisplayPath presents an item under one of its alias paths when set.
+	DisplayPath func(reg *entities.Registry) string
+
+	// DisplayText rewrites the path, value and decoded columns, e.g. to resolve
+	// SIDs and GUIDs, when set.
+	DisplayText func(s string) string
+
+	// DisplayExpanded returns the expanded form of REG_EXPAND_SZ values, empty
+	// for other items, when set.
+	DisplayExpanded func(reg *entities.Registry) string
 }
 
 func NewRegistryTableModel() *RegistryTableModel {
@@ -28,14 +41,45 @@ func (m *RegistryTableModel) Value(row, col int) interface{} {
 
 	switch col {
 	case 0:
-		return item.Path
+		path := item.Path
+		if m.DisplayPath != nil {
+			path = m.DisplayPath(item)
+		}
+		return m.displayText(path)
 	case 1:
 		return item.Name
 	case 2:
 		return item.Type
 	case 3:
-		return item.Value
+		return m.displayText(item.Value)
+	case 4:
+		if m.DisplayExpanded == nil {
+			return ""
+		}
+		return m.displayText(m.DisplayExpanded(item))
+	case 5:
+		if item.Decoded == nil {
+			return ""
+		}
+		return m.displayText(item.Decoded.Summary)
+	case 6:
+		if item.Policy == nil {
+			return ""
+		}
+		return item.Policy.DisplayName + ": " + item.Policy.Meaning
+	case 7:
+		return strings.Join(item.Aliases, "; ")
+	case 8:
+		return item.Origin
 	}
 
 	panic("unexpected col")
 }
+
+func (m *RegistryTableModel) displayText(s string) string {
+
+	if m.DisplayText != nil {
+		return m.DisplayText(s)
+	}
+	return s
+}
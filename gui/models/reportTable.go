@@ -0,0 +1,64 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/lxn/walk"
+)
+
+type ReportTableModel struct {
+	walk.TableModelBase
+	walk.SorterBase
+	Rows [][]string
+}
+
+func NewReportTableModel() *ReportTableModel {
+
+	m := new(ReportTableModel)
+	m.RowsReset()
+	return m
+}
+
+func (m *ReportTableModel) RowCount() int {
+
+	return len(m.Rows)
+}
+
+func (m *ReportTableModel) Value(row, col int) interface{} {
+
+	return m.Rows[row][col]
+}
+
+// Sort orders rows by the column, numbers by value before text.
+func (m *ReportTableModel) Sort(col int, order walk.SortOrder) error {
+
+	sort.SliceStable(m.Rows, func(i, j int) bool {
+		a, b := m.Rows[i][col], m.Rows[j][col]
+		if order == walk.SortDescending {
+			return cellLess(b, a)
+		}
+		return cellLess(a, b)
+	})
+
+	return m.SorterBase.Sort(col, order)
+}
+
+// cellLess is a total order over cells: numbers by value, then text, with
+// ties such as "01" and "1" broken on the raw string.
+func cellLess(a string, b string) bool {
+
+	x, errX := strconv.ParseFloat(a, 64)
+	y, errY := strconv.ParseFloat(b, 64)
+	numA, numB := errX == nil && !math.IsNaN(x), errY == nil && !math.IsNaN(y)
+
+	switch {
+	case numA && numB && x != y:
+		return x < y
+	case numA != numB:
+		return numA
+	default:
+		return a < b
+	}
+}
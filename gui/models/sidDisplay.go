@@ -0,0 +1,10 @@
+package models
+
+import "github.com/0736b/registry-finder-gui/utils"
+
+var sidDisplayModel []string = []string{utils.STR_SID_DISPLAY_RAW, utils.STR_SID_DISPLAY_ANNOTATE, utils.STR_SID_DISPLAY_SUBSTITUTE}
+
+func NewSidDisplayModel() *[]string {
+
+	return &sidDisplayModel
+}
@@ -0,0 +1,108 @@
+package gui
+
+import (
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/gui/models"
+	"github.com/0736b/registry-finder-gui/usecases"
+	"github.com/lxn/walk"
+
+	//lint:ignore ST1001 don't worry trust me
+	. "github.com/lxn/walk/declarative"
+)
+
+const (
+	QUERY_EFFECTIVE_COLUMN string = "Effective"
+)
+
+var effectiveHighlight = walk.RGB(0xd9, 0xf2, 0xd9)
+
+// showQueryReport opens a window that builds a report for the typed query,
+// such as an extension or a CLSID, from the entities collected so far. Rows
+// marked in an Effective column are highlighted.
+func (app *AppWindow) showQueryReport(title string, cue string, resolve func(regs []*entities.Registry, query string) *entities.Report) {
+
+	var dlg *walk.Dialog
+	var queryBox *walk.LineEdit
+	var table *walk.TableView
+
+	regs := app.reportSnapshot()
+	model := models.NewReportTableModel()
+	report := resolve(regs, "")
+
+	columns := make([]TableViewColumn, 0, len(report.Columns))
+	for _, title := range report.Columns {
+		columns = append(columns, TableViewColumn{Title: title, Width: REPORT_WIDTH / len(report.Columns)})
+	}
+
+	effectiveCol := -1
+	for i, title := range report.Columns {
+		if title == QUERY_EFFECTIVE_COLUMN {
+			effectiveCol = i
+		}
+	}
+
+	runQuery := func() {
+		report = resolve(regs, queryBox.Text())
+		model.Rows = report.Rows
+		model.PublishRowsReset()
+		dlg.SetTitle(report.Title)
+	}
+
+	exportRows := func() {
+		fileDlg := &walk.FileDialog{Title: "Export " + report.Title, Filter: EXPORT_FILTER}
+		accepted, err := fileDlg.ShowSave(dlg)
+		if err != nil || !accepted {
+			return
+		}
+		shown := &entities.Report{Title: report.Title, Columns: report.Columns, Rows: model.Rows, RangeColumns: report.RangeColumns}
+		if err := app.usecase.ExportReport(fileDlg.FilePath, shown); err != nil {
+			walk.MsgBox(dlg, "Export "+report.Title, err.Error(), walk.MsgBoxIconError)
+		}
+	}
+
+	d := Dialog{
+		AssignTo: &dlg,
+		Title:    title,
+		MinSize:  Size{Width: REPORT_WIDTH, Height: REPORT_HEIGHT},
+		Layout:   VBox{},
+		Children: []Widget{
+			Composite{
+				Layout: HBox{MarginsZero: true},
+				Children: []Widget{
+					LineEdit{
+						AssignTo:  &queryBox,
+						CueBanner: cue,
+						OnKeyPress: func(key walk.Key) {
+							if key == walk.KeyReturn {
+								runQuery()
+							}
+						},
+					},
+					PushButton{
+						Text:      "Resolve",
+						OnClicked: runQuery,
+					},
+					PushButton{
+						Text:      "Export...",
+						OnClicked: exportRows,
+					},
+				},
+			},
+			TableView{
+				AssignTo:         &table,
+				AlternatingRowBG: true,
+				Columns:          columns,
+				Model:            model,
+				StyleCell: func(style *walk.CellStyle) {
+					if effectiveCol >= 0 && style.Row() < len(model.Rows) && model.Rows[style.Row()][effectiveCol] == usecases.STR_CLASSES_EFFECTIVE {
+						style.BackgroundColor = effectiveHighlight
+					}
+				},
+			},
+		},
+	}
+
+	if _, err := d.Run(app); err != nil {
+		walk.MsgBox(app, title, err.Error(), walk.MsgBoxIconError)
+	}
+}
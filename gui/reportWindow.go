@@ -0,0 +1,88 @@
+package gui
+
+import (
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/gui/models"
+	"github.com/lxn/walk"
+
+	//lint:ignore ST1001 don't worry trust me
+	. "github.com/lxn/walk/declarative"
+)
+
+const (
+	REPORT_WIDTH  int = 900
+	REPORT_HEIGHT int = 600
+)
+
+// showReport opens a derived report in its own window with a search box that
+// filters its rows and a button to export what is shown.
+func (app *AppWindow) showReport(report *entities.Report) {
+
+	var dlg *walk.Dialog
+	var searchBox *walk.LineEdit
+	var table *walk.TableView
+
+	model := models.NewReportTableModel()
+	model.Rows = report.Rows
+
+	columns := make([]TableViewColumn, 0, len(report.Columns))
+	for _, title := range report.Columns {
+		columns = append(columns, TableViewColumn{Title: title, Width: REPORT_WIDTH / len(report.Columns)})
+	}
+
+	filterRows := func() {
+		query := searchBox.Text()
+		rows := make([][]string, 0, len(report.Rows))
+		for _, row := range report.Rows {
+			if app.usecase.FilterReportRow(report, row, query) {
+				rows = append(rows, row)
+			}
+		}
+		model.Rows = rows
+		model.PublishRowsReset()
+	}
+
+	exportRows := func() {
+		fileDlg := &walk.FileDialog{Title: "Export " + report.Title, Filter: EXPORT_FILTER}
+		accepted, err := fileDlg.ShowSave(dlg)
+		if err != nil || !accepted {
+			return
+		}
+		shown := &entities.Report{Title: report.Title, Columns: report.Columns, Rows: model.Rows, RangeColumns: report.RangeColumns}
+		if err := app.usecase.ExportReport(fileDlg.FilePath, shown); err != nil {
+			walk.MsgBox(dlg, "Export "+report.Title, err.Error(), walk.MsgBoxIconError)
+		}
+	}
+
+	d := Dialog{
+		AssignTo: &dlg,
+		Title:    report.Title,
+		MinSize:  Size{Width: REPORT_WIDTH, Height: REPORT_HEIGHT},
+		Layout:   VBox{},
+		Children: []Widget{
+			Composite{
+				Layout: HBox{MarginsZero: true},
+				Children: []Widget{
+					LineEdit{
+						AssignTo:      &searchBox,
+						OnTextChanged: filterRows,
+					},
+					PushButton{
+						Text:      "Export...",
+						OnClicked: exportRows,
+					},
+				},
+			},
+			TableView{
+				AssignTo:         &table,
+				AlternatingRowBG: true,
+				Columns:          columns,
+				Model:            model,
+			},
+		},
+	}
+
+	if _, err := d.Run(app); err != nil {
+		walk.MsgBox(app, report.Title, err.Error(), walk.MsgBoxIconError)
+	}
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"flag"
 	"log"
+	"os"
+	"path/filepath"
 
 	"github.com/0736b/registry-finder-gui/gui"
 	"github.com/0736b/registry-finder-gui/usecases"
@@ -20,7 +23,28 @@ func main() {
 	// }
 	// defer pprof.StopCPUProfile()
 
+	policyDefs := flag.String("policydefs", "", "directory of ADMX/ADML files used to annotate policy values, defaults to the PolicyDefinitions of -image or of this system")
+	imageRoot := flag.String("image", "", "root of a mounted Windows volume to load hives from instead of the live registry")
+	imageUser := flag.String("user", "", "profile directory or SID shown as HKEY_CURRENT_USER and merged into HKEY_CLASSES_ROOT for -image, empty picks the most recently used")
+	dedupe := flag.Bool("dedupe", false, "scan each physical live key once and show HKCU, HKCR and HKCC entries as aliases")
+	asepCatalogue := flag.String("asep", "", "JSON catalogue of autostart locations for the persistence report, empty uses the bundled one")
+	envFile := flag.String("envfile", "", "NAME=VALUE file of variables REG_EXPAND_SZ values are expanded with, empty uses the live or -image environment")
+	flag.Parse()
+
+	if *policyDefs == "" {
+		*policyDefs = defaultPolicyDefinitions(*imageRoot)
+	}
+
 	usecase := usecases.NewRegistryUsecase()
+	if *dedupe {
+		usecase = usecases.NewDedupedRegistryUsecase()
+	}
+	if *imageRoot != "" {
+		usecase = usecases.NewImageRegistryUsecase(*imageRoot, *imageUser)
+	}
+	usecase.UsePolicyDefinitions(*policyDefs)
+	usecase.UseAsepCatalogue(*asepCatalogue)
+	usecase.UseEnvironmentFile(*envFile)
 
 	app, err := gui.NewAppWindow(usecase)
 	if err != nil {
@@ -30,3 +54,16 @@ func main() {
 	app.Run()
 
 }
+
+// defaultPolicyDefinitions is the PolicyDefinitions directory of the mounted
+// image, or of this system, empty when SystemRoot is not set.
+func defaultPolicyDefinitions(imageRoot string) string {
+
+	if imageRoot != "" {
+		return filepath.Join(imageRoot, "Windows", "PolicyDefinitions")
+	}
+	if systemRoot := os.Getenv("SystemRoot"); systemRoot != "" {
+		return filepath.Join(systemRoot, "PolicyDefinitions")
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/0736b/registry-finder-gui/entities"
+)
+
+// asep.json is the default catalogue and the template for user-edited ones.
+//
+//go:embed asep.json
+var defaultAsepCatalogue []byte
+
+type AsepRepository interface {
+	LoadCatalogue(path string) ([]*entities.AsepLocation, error)
+}
+
+type AsepRepositoryImpl struct{}
+
+func NewAsepRepository() *AsepRepositoryImpl {
+	return &AsepRepositoryImpl{}
+}
+
+// LoadCatalogue reads the ASEP catalogue at path, or the bundled one when path
+// is empty.
+func (r *AsepRepositoryImpl) LoadCatalogue(path string) ([]*entities.AsepLocation, error) {
+
+	data := defaultAsepCatalogue
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read asep catalogue: %w", err)
+		}
+	}
+
+	var locations []*entities.AsepLocation
+	if err := json.Unmarshal(data, &locations); err != nil {
+		return nil, fmt.Errorf("failed to parse asep catalogue: %w", err)
+	}
+
+	for i, location := range locations {
+		if location.Path == "" {
+			return nil, fmt.Errorf("asep catalogue entry %d (%s) has no path", i, location.Name)
+		}
+	}
+
+	return locations, nil
+}
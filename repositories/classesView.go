@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/repositories/regf"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_MACHINE_CLASSES string = IMAGE_MOUNT_SOFTWARE + "\\Classes"
+)
+
+// selectClassesUser picks the UsrClass.dat merged into HKCR: the requested
+// profile directory or SID, otherwise the most recently written one.
+func selectClassesUser(mounts []*mountedHive, user string) *mountedHive {
+
+	var selected *mountedHive
+	var selectedMod int64
+
+	for _, m := range mounts {
+
+		if !strings.HasPrefix(m.mount, utils.STR_HKEY_USERS+"\\") || !strings.HasSuffix(m.mount, IMAGE_CLASSES_SUFFIX) {
+			continue
+		}
+
+		if user != "" {
+			sid := strings.TrimSuffix(m.mount[len(utils.STR_HKEY_USERS)+1:], IMAGE_CLASSES_SUFFIX)
+			if strings.EqualFold(m.profile, user) || strings.EqualFold(sid, user) {
+				return m
+			}
+			continue
+		}
+
+		info, err := os.Stat(m.file)
+		if err != nil {
+			continue
+		}
+		if selected == nil || info.ModTime().Unix() > selectedMod {
+			selected, selectedMod = m, info.ModTime().Unix()
+		}
+	}
+
+	return selected
+}
+
+// generateMergedClasses synthesizes HKEY_CLASSES_ROOT the way Windows does:
+// keys present in the user's classes hive win over HKLM\SOFTWARE\Classes and
+// subkeys are the union of both. Each entity records the key it came from.
+func generateMergedClasses(machine *mountedHive, user *mountedHive) <-chan *entities.Registry {
+
+	regChan := make(chan *entities.Registry)
+
+	go func() {
+		defer close(regChan)
+
+		var machineClasses, userClasses *regf.Key
+
+		if err := machine.load(); err != nil {
+			log.Println("generateMergedClasses load machine failed", err.Error())
+		} else if root, err := machine.hive.RootKey(); err == nil {
+			machineClasses = root.Subkey("Classes")
+		}
+
+		userOrigin := ""
+		if user != nil {
+			if err := user.load(); err != nil {
+				log.Println("generateMergedClasses load user failed", err.Error())
+			} else if root, err := user.hive.RootKey(); err == nil {
+				userClasses, userOrigin = root, user.mount
+			}
+		}
+
+		if machineClasses == nil && userClasses == nil {
+			return
+		}
+
+		walkMergedClasses(machineClasses, userClasses, "", 0, make(regf.KeySet), STR_MACHINE_CLASSES, userOrigin, regChan)
+	}()
+
+	return regChan
+}
+
+func walkMergedClasses(machine *regf.Key, user *regf.Key, rel string, depth int, seen regf.KeySet, machineOrigin string, userOrigin string, regChan chan *entities.Registry) {
+
+	if depth > regf.HIVE_MAX_KEY_DEPTH || (machine != nil && !seen.Add(machine)) || (user != nil && !seen.Add(user)) {
+		log.Println("walkMergedClasses skipped", utils.STR_HKEY_CLASSES_ROOT+rel, "nested too deep or visited before")
+		return
+	}
+
+	if user != nil {
+		emitHiveKey(user, utils.STR_HKEY_CLASSES_ROOT+rel, nil, userOrigin+rel, regChan)
+	} else {
+		emitHiveKey(machine, utils.STR_HKEY_CLASSES_ROOT+rel, nil, machineOrigin+rel, regChan)
+	}
+
+	type pair struct {
+		name    string
+		machine *regf.Key
+		user    *regf.Key
+	}
+
+	pairs := make([]*pair, 0)
+	byName := make(map[string]*pair)
+
+	if machine != nil {
+		subs, _ := machine.Subkeys()
+		for _, sub := range subs {
+			p := &pair{name: sub.Name, machine: sub}
+			pairs = append(pairs, p)
+			byName[strings.ToLower(sub.Name)] = p
+		}
+	}
+
+	if user != nil {
+		subs, _ := user.Subkeys()
+		for _, sub := range subs {
+			if p, ok := byName[strings.ToLower(sub.Name)]; ok {
+				p.user = sub
+				continue
+			}
+			pairs = append(pairs, &pair{name: sub.Name, user: sub})
+		}
+	}
+
+	for _, p := range pairs {
+		walkMergedClasses(p.machine, p.user, rel+"\\"+p.name, depth+1, seen, machineOrigin, userOrigin, regChan)
+	}
+}
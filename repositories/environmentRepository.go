@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type EnvironmentRepository interface {
+	LoadEnvironment(path string) (map[string]string, error)
+}
+
+type EnvironmentRepositoryImpl struct{}
+
+func NewEnvironmentRepository() *EnvironmentRepositoryImpl {
+	return &EnvironmentRepositoryImpl{}
+}
+
+// LoadEnvironment reads NAME=VALUE lines, as printed by `set`, from the file
+// at path. Blank lines and lines starting with # or ; are skipped.
+func (r *EnvironmentRepositoryImpl) LoadEnvironment(path string) (map[string]string, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read environment file: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	variables := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+
+		// names of hidden per-drive variables such as =C: start with =
+		name, value, ok := strings.Cut(text[1:], "=")
+		if !ok {
+			return nil, fmt.Errorf("environment file line %d has no '='", line)
+		}
+		variables[text[:1]+name] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read environment file: %w", err)
+	}
+
+	return variables, nil
+}
@@ -0,0 +1,378 @@
+package repositories
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/repositories/regf"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	IMAGE_MOUNT_AMCACHE  string = utils.STR_HKEY_LOCAL_MACHINE + "\\Amcache"
+	IMAGE_MOUNT_REGBACK  string = utils.STR_HKEY_LOCAL_MACHINE + "\\RegBack"
+	IMAGE_MOUNT_DEFAULT  string = utils.STR_HKEY_USERS + "\\.DEFAULT"
+	IMAGE_MOUNT_SOFTWARE string = utils.STR_HKEY_LOCAL_MACHINE + "\\SOFTWARE"
+	IMAGE_CLASSES_SUFFIX string = "_Classes"
+
+	IMAGE_USERS_DIR    string = "Users"
+	IMAGE_NTUSER_FILE  string = "NTUSER.DAT"
+	IMAGE_REGBACK_DIR  string = "RegBack"
+	IMAGE_PROFILE_LIST string = "Microsoft\\Windows NT\\CurrentVersion\\ProfileList"
+)
+
+var (
+	imageConfigDir    = []string{"Windows", "System32", "config"}
+	imageSystemHives  = []string{"SYSTEM", "SOFTWARE", "SAM", "SECURITY", "DEFAULT"}
+	imageAmcachePath  = []string{"Windows", "AppCompat", "Programs", "Amcache.hve"}
+	imageUsrClassPath = []string{"AppData", "Local", "Microsoft", "Windows", "UsrClass.dat"}
+	imageServiceUsers = map[string][]string{"S-1-5-19": {"Windows", "ServiceProfiles", "LocalService"}, "S-1-5-20": {"Windows", "ServiceProfiles", "NetworkService"}}
+)
+
+// ImageRegistryRepositoryImpl streams every hive found under the root of a
+// mounted Windows volume, each mounted under its live-equivalent path.
+type ImageRegistryRepositoryImpl struct {
+	root string
+	user string
+}
+
+type mountedHive struct {
+	file    string
+	mount   string
+	profile string
+	hive    *regf.Hive
+	aliases []*entities.PathAlias
+}
+
+// NewImageRegistryRepository mounts the hives under root; user selects the
+// profile (directory name or SID) whose classes are merged into HKCR.
+func NewImageRegistryRepository(root string, user string) *ImageRegistryRepositoryImpl {
+	return &ImageRegistryRepositoryImpl{root: root, user: user}
+}
+
+func (r *ImageRegistryRepositoryImpl) StreamRegistry() <-chan *entities.Registry {
+
+	mounts := r.discoverHives()
+
+	classes := selectClassesUser(mounts, r.user)
+	aliasCurrentUser(selectUserHive(mounts, classes, r.user), classes)
+
+	gens := make([]<-chan *entities.Registry, 0, len(mounts)+1)
+	for _, m := range mounts {
+		gens = append(gens, generateRegByHive(m))
+	}
+
+	if machine := findMount(mounts, IMAGE_MOUNT_SOFTWARE); machine != nil {
+		gens = append(gens, generateMergedClasses(machine, classes))
+	}
+
+	return fanInGenerate(gens...)
+}
+
+func (r *ImageRegistryRepositoryImpl) discoverHives() []*mountedHive {
+
+	mounts := make([]*mountedHive, 0)
+
+	for _, name := range imageSystemHives {
+		file := findImagePath(r.root, append(imageConfigDir, name)...)
+		if file == "" {
+			continue
+		}
+		mount := utils.STR_HKEY_LOCAL_MACHINE + "\\" + name
+		if name == "DEFAULT" {
+			mount = IMAGE_MOUNT_DEFAULT
+		}
+		mounts = append(mounts, &mountedHive{file: file, mount: mount})
+	}
+
+	profileSids := make(map[string]string)
+	for _, m := range mounts {
+		switch m.mount {
+		case IMAGE_MOUNT_SOFTWARE:
+			profileSids = readProfileSids(m)
+		case STR_SYSTEM_KEY:
+			m.aliases = readControlSetAliases(m)
+		}
+	}
+
+	for _, name := range imageSystemHives {
+		file := findImagePath(r.root, append(append(imageConfigDir, IMAGE_REGBACK_DIR), name)...)
+		if info, err := os.Stat(file); file == "" || err != nil || info.Size() == 0 {
+			continue
+		}
+		mounts = append(mounts, &mountedHive{file: file, mount: IMAGE_MOUNT_REGBACK + "\\" + name})
+	}
+
+	if file := findImagePath(r.root, imageAmcachePath...); file != "" {
+		mounts = append(mounts, &mountedHive{file: file, mount: IMAGE_MOUNT_AMCACHE})
+	}
+
+	for sid, profile := range imageServiceUsers {
+		if file := findImagePath(r.root, append(profile, IMAGE_NTUSER_FILE)...); file != "" {
+			mounts = append(mounts, &mountedHive{file: file, mount: utils.STR_HKEY_USERS + "\\" + sid})
+		}
+	}
+
+	usersDir := findImagePath(r.root, IMAGE_USERS_DIR)
+	entries, _ := os.ReadDir(usersDir)
+	for _, entry := range entries {
+
+		if !entry.IsDir() {
+			continue
+		}
+
+		sid, ok := profileSids[strings.ToLower(entry.Name())]
+		if !ok {
+			sid = entry.Name()
+		}
+
+		if file := findImagePath(usersDir, entry.Name(), IMAGE_NTUSER_FILE); file != "" {
+			mounts = append(mounts, &mountedHive{file: file, mount: utils.STR_HKEY_USERS + "\\" + sid, profile: entry.Name()})
+		}
+		if file := findImagePath(usersDir, append([]string{entry.Name()}, imageUsrClassPath...)...); file != "" {
+			mounts = append(mounts, &mountedHive{file: file, mount: utils.STR_HKEY_USERS + "\\" + sid + IMAGE_CLASSES_SUFFIX, profile: entry.Name()})
+		}
+	}
+
+	return mounts
+}
+
+func findMount(mounts []*mountedHive, mount string) *mountedHive {
+
+	for _, m := range mounts {
+		if m.mount == mount {
+			return m
+		}
+	}
+	return nil
+}
+
+// selectUserHive picks the NTUSER.DAT standing in for the logged on user: the
+// one of the profile whose classes were selected, else the requested profile
+// directory or SID, else the most recently written one.
+func selectUserHive(mounts []*mountedHive, classes *mountedHive, user string) *mountedHive {
+
+	var selected *mountedHive
+	var selectedMod int64
+
+	for _, m := range mounts {
+
+		if m.profile == "" || strings.HasSuffix(m.mount, IMAGE_CLASSES_SUFFIX) {
+			continue
+		}
+
+		if classes != nil {
+			if m.mount+IMAGE_CLASSES_SUFFIX == classes.mount {
+				return m
+			}
+			continue
+		}
+
+		if user != "" {
+			if strings.EqualFold(m.profile, user) || strings.EqualFold(m.mount[len(utils.STR_HKEY_USERS)+1:], user) {
+				return m
+			}
+			continue
+		}
+
+		info, err := os.Stat(m.file)
+		if err != nil {
+			continue
+		}
+		if selected == nil || info.ModTime().Unix() > selectedMod {
+			selected, selectedMod = m, info.ModTime().Unix()
+		}
+	}
+
+	return selected
+}
+
+// aliasCurrentUser reports the selected profile's hives under
+// HKEY_CURRENT_USER the way a live scan of that user's session would.
+func aliasCurrentUser(ntuser *mountedHive, classes *mountedHive) {
+
+	if ntuser != nil {
+		ntuser.aliases = append(ntuser.aliases, &entities.PathAlias{Canonical: ntuser.mount, Alias: utils.STR_HKEY_CURRENT_USER})
+	}
+
+	if classes != nil {
+		userKey := strings.TrimSuffix(classes.mount, IMAGE_CLASSES_SUFFIX)
+		classes.aliases = append(classes.aliases,
+			&entities.PathAlias{Canonical: classes.mount, Alias: utils.STR_HKEY_CURRENT_USER + "\\Software\\Classes"},
+			&entities.PathAlias{Canonical: classes.mount, Alias: userKey + "\\Software\\Classes"},
+		)
+	}
+}
+
+// readProfileSids maps lower-cased profile directory names to the SIDs listed
+// in the SOFTWARE hive ProfileList.
+func readProfileSids(m *mountedHive) map[string]string {
+
+	sids := make(map[string]string)
+
+	if err := m.load(); err != nil {
+		log.Println("readProfileSids load failed", err.Error())
+		return sids
+	}
+
+	root, err := m.hive.RootKey()
+	if err != nil {
+		return sids
+	}
+
+	profileList := root.Subkey(IMAGE_PROFILE_LIST)
+	if profileList == nil {
+		return sids
+	}
+
+	profiles, _ := profileList.Subkeys()
+	for _, profile := range profiles {
+		val := profile.Value("ProfileImagePath")
+		if val == nil {
+			continue
+		}
+		imagePath := utils.UTF16ToString(val.Data)
+		if i := strings.LastIndex(imagePath, "\\"); i >= 0 {
+			sids[strings.ToLower(imagePath[i+1:])] = profile.Name
+		}
+	}
+
+	return sids
+}
+
+func readControlSetAliases(m *mountedHive) []*entities.PathAlias {
+
+	if err := m.load(); err != nil {
+		log.Println("readControlSetAliases load failed", err.Error())
+		return nil
+	}
+
+	root, err := m.hive.RootKey()
+	if err != nil {
+		return nil
+	}
+
+	sel := root.Subkey("Select")
+	if sel == nil {
+		return nil
+	}
+
+	current := sel.Value("Current")
+	if current == nil {
+		return nil
+	}
+
+	var currentConfig uint32
+	if idConfig := root.Subkey(fmt.Sprintf("ControlSet%03d\\Control\\IDConfigDB", dwordOf(current.Data))); idConfig != nil {
+		if val := idConfig.Value("CurrentConfig"); val != nil {
+			currentConfig = dwordOf(val.Data)
+		}
+	}
+
+	return controlSetAliases(dwordOf(current.Data), currentConfig)
+}
+
+func (m *mountedHive) load() error {
+
+	if m.hive != nil {
+		return nil
+	}
+
+	h, err := regf.Open(m.file)
+	if err != nil {
+		return err
+	}
+	m.hive = h
+
+	return nil
+}
+
+func generateRegByHive(m *mountedHive) <-chan *entities.Registry {
+
+	regChan := make(chan *entities.Registry)
+
+	go func() {
+		defer close(regChan)
+
+		if err := m.load(); err != nil {
+			log.Println("generateRegByHive load failed", err.Error())
+			return
+		}
+
+		root, err := m.hive.RootKey()
+		if err != nil {
+			log.Println("generateRegByHive rootKey failed", err.Error())
+			return
+		}
+
+		walkHiveKey(root, m.mount, m.aliases, regChan)
+	}()
+
+	return regChan
+}
+
+func walkHiveKey(key *regf.Key, path string, aliases []*entities.PathAlias, regChan chan *entities.Registry) {
+
+	err := key.Walk(path, func(path string, key *regf.Key) {
+		emitHiveKey(key, path, aliasesOf(path, aliases), "", regChan)
+	})
+	if err != nil {
+		log.Println("walkHiveKey Walk failed", err.Error())
+	}
+}
+
+func emitHiveKey(key *regf.Key, path string, aliases []string, origin string, regChan chan *entities.Registry) {
+
+	regChan <- &entities.Registry{Path: path, Name: "", Type: "", Value: "", Aliases: aliases, Origin: origin, LastWrite: key.LastWrite}
+
+	values, err := key.Values()
+	if err != nil {
+		log.Println("emitHiveKey values failed", path, err.Error())
+	}
+
+	for _, val := range values {
+		strValue, typeStr, strs := formatValue(val.Data, val.Type)
+		regChan <- &entities.Registry{Path: path, Name: val.Name, Type: typeStr, Value: strValue, Strings: strs, Aliases: aliases, Origin: origin, LastWrite: key.LastWrite}
+	}
+}
+
+// findImagePath resolves a path below root matching each element case-insensitively,
+// since images mounted on case-sensitive filesystems keep the on-disk casing.
+func findImagePath(root string, elems ...string) string {
+
+	if root == "" {
+		return ""
+	}
+
+	curr := root
+	for _, elem := range elems {
+
+		if _, err := os.Stat(filepath.Join(curr, elem)); err == nil {
+			curr = filepath.Join(curr, elem)
+			continue
+		}
+
+		entries, err := os.ReadDir(curr)
+		if err != nil {
+			return ""
+		}
+
+		found := false
+		for _, entry := range entries {
+			if strings.EqualFold(entry.Name(), elem) {
+				curr = filepath.Join(curr, entry.Name())
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ""
+		}
+	}
+
+	return curr
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const STR_TEST_SID string = "S-1-5-21-1-2-3-1001"
+
+func testUserMounts() []*mountedHive {
+
+	user := utils.STR_HKEY_USERS + "\\" + STR_TEST_SID
+	return []*mountedHive{
+		{file: "SOFTWARE", mount: IMAGE_MOUNT_SOFTWARE},
+		{file: "DEFAULT", mount: IMAGE_MOUNT_DEFAULT},
+		{file: "other", mount: utils.STR_HKEY_USERS + "\\S-1-5-21-1-2-3-1002", profile: "bob"},
+		{file: "ntuser", mount: user, profile: "alice"},
+		{file: "usrclass", mount: user + IMAGE_CLASSES_SUFFIX, profile: "alice"},
+	}
+}
+
+func TestSelectUserHive(t *testing.T) {
+
+	mounts := testUserMounts()
+
+	tests := []struct {
+		name    string
+		classes *mountedHive
+		user    string
+		want    string
+	}{
+		{"profile of the selected classes", mounts[4], "", "ntuser"},
+		{"profile directory", nil, "Alice", "ntuser"},
+		{"SID", nil, STR_TEST_SID, "ntuser"},
+		{"profile without classes", nil, "bob", "other"},
+		{"unknown profile", nil, "carol", ""},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if m := selectUserHive(mounts, tt.classes, tt.user); m != nil {
+			got = m.file
+		}
+		if got != tt.want {
+			t.Errorf("%s: selected %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAliasCurrentUser(t *testing.T) {
+
+	mounts := testUserMounts()
+	ntuser, classes := mounts[3], mounts[4]
+	aliasCurrentUser(ntuser, classes)
+
+	tests := []struct {
+		path string
+		m    *mountedHive
+		want []string
+	}{
+		{ntuser.mount + "\\Software\\Microsoft", ntuser, []string{utils.STR_HKEY_CURRENT_USER + "\\Software\\Microsoft"}},
+		{classes.mount + "\\.txt", classes, []string{utils.STR_HKEY_CURRENT_USER + "\\Software\\Classes\\.txt", ntuser.mount + "\\Software\\Classes\\.txt"}},
+		{mounts[2].mount + "\\Software", mounts[2], nil},
+	}
+
+	for _, tt := range tests {
+		if got := aliasesOf(tt.path, tt.m.aliases); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("aliases of %s = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// liveScan holds the keys a deduplicated live scan must not descend into and
+// the alias paths reported for the physical keys it does walk.
+type liveScan struct {
+	skip    map[string]bool
+	aliases []*entities.PathAlias
+}
+
+func newDedupedScan() *liveScan {
+
+	scan := &liveScan{skip: make(map[string]bool)}
+
+	current, currentConfig := readLiveControlSet()
+	scan.aliases = append(scan.aliases, controlSetAliases(current, currentConfig)...)
+	scan.addSkip(STR_CURRENT_CONTROL_SET)
+	if current != 0 {
+		scan.addSkip(fmt.Sprintf("%s\\ControlSet%03d\\Hardware Profiles\\Current", STR_SYSTEM_KEY, current))
+	}
+
+	scan.aliases = append(scan.aliases, &entities.PathAlias{Canonical: STR_MACHINE_CLASSES, Alias: utils.STR_HKEY_CLASSES_ROOT})
+
+	sid, err := currentUserSid()
+	if err != nil {
+		log.Println("newDedupedScan currentUserSid failed", err.Error())
+		return scan
+	}
+
+	userKey := utils.STR_HKEY_USERS + "\\" + sid
+	userClasses := userKey + IMAGE_CLASSES_SUFFIX
+
+	scan.addSkip(userKey + "\\Software\\Classes")
+	scan.aliases = append(scan.aliases,
+		&entities.PathAlias{Canonical: userKey, Alias: utils.STR_HKEY_CURRENT_USER},
+		&entities.PathAlias{Canonical: userClasses, Alias: utils.STR_HKEY_CLASSES_ROOT},
+		&entities.PathAlias{Canonical: userClasses, Alias: utils.STR_HKEY_CURRENT_USER + "\\Software\\Classes"},
+		&entities.PathAlias{Canonical: userClasses, Alias: userKey + "\\Software\\Classes"},
+	)
+
+	return scan
+}
+
+func (s *liveScan) addSkip(path string) {
+
+	s.skip[strings.ToLower(path)] = true
+}
+
+func (s *liveScan) skips(path string) bool {
+
+	return s != nil && s.skip[strings.ToLower(path)]
+}
+
+func (s *liveScan) aliasesOf(path string) []string {
+
+	if s == nil {
+		return nil
+	}
+	return aliasesOf(path, s.aliases)
+}
+
+func readLiveControlSet() (uint32, uint32) {
+
+	sel, err := registry.OpenKey(registry.LOCAL_MACHINE, "SYSTEM\\Select", registry.QUERY_VALUE)
+	if err != nil {
+		return 0, 0
+	}
+	defer sel.Close()
+
+	current, _, err := sel.GetIntegerValue("Current")
+	if err != nil {
+		return 0, 0
+	}
+
+	idConfig, err := registry.OpenKey(registry.LOCAL_MACHINE, fmt.Sprintf("SYSTEM\\ControlSet%03d\\Control\\IDConfigDB", current), registry.QUERY_VALUE)
+	if err != nil {
+		return uint32(current), 0
+	}
+	defer idConfig.Close()
+
+	currentConfig, _, _ := idConfig.GetIntegerValue("CurrentConfig")
+
+	return uint32(current), uint32(currentConfig)
+}
+
+func currentUserSid() (string, error) {
+
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return "", fmt.Errorf("failed to get token user: %w", err)
+	}
+	return user.User.Sid.String(), nil
+}
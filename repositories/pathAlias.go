@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_SYSTEM_KEY          string = utils.STR_HKEY_LOCAL_MACHINE + "\\SYSTEM"
+	STR_CURRENT_CONTROL_SET string = STR_SYSTEM_KEY + "\\CurrentControlSet"
+)
+
+// controlSetAliases returns the virtual paths Windows resolves at runtime:
+// CurrentControlSet for the control set selected in Select\Current and
+// HKEY_CURRENT_CONFIG for the hardware profile in IDConfigDB\CurrentConfig.
+func controlSetAliases(current uint32, currentConfig uint32) []*entities.PathAlias {
+
+	if current == 0 {
+		return nil
+	}
+
+	controlSet := fmt.Sprintf("%s\\ControlSet%03d", STR_SYSTEM_KEY, current)
+	aliases := []*entities.PathAlias{{Canonical: controlSet, Alias: STR_CURRENT_CONTROL_SET}}
+
+	if currentConfig != 0 {
+		aliases = append(aliases, &entities.PathAlias{
+			Canonical: fmt.Sprintf("%s\\Hardware Profiles\\%04d", controlSet, currentConfig),
+			Alias:     utils.STR_HKEY_CURRENT_CONFIG,
+		})
+	}
+
+	return aliases
+}
+
+func aliasesOf(path string, aliases []*entities.PathAlias) []string {
+
+	var result []string
+	for _, a := range aliases {
+		if !utils.HasPrefixFold(path, a.Canonical) {
+			continue
+		}
+		rest := path[len(a.Canonical):]
+		if rest != "" && rest[0] != '\\' {
+			continue
+		}
+		result = append(result, a.Alias+rest)
+	}
+	return result
+}
+
+func dwordOf(data []byte) uint32 {
+
+	if len(data) < 4 {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(data)
+}
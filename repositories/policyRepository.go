@@ -0,0 +1,342 @@
+package repositories
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	POLICY_DEFAULT_LANGUAGE string = "en-US"
+)
+
+type PolicyRepository interface {
+	LoadPolicyDefinitions(dir string) ([]*entities.Policy, error)
+}
+
+type PolicyRepositoryImpl struct{}
+
+func NewPolicyRepository() *PolicyRepositoryImpl {
+	return &PolicyRepositoryImpl{}
+}
+
+type admxFile struct {
+	Namespaces struct {
+		Target struct {
+			Prefix    string `xml:"prefix,attr"`
+			Namespace string `xml:"namespace,attr"`
+		} `xml:"target"`
+		Using []struct {
+			Prefix    string `xml:"prefix,attr"`
+			Namespace string `xml:"namespace,attr"`
+		} `xml:"using"`
+	} `xml:"policyNamespaces"`
+	Categories []admxCategory `xml:"categories>category"`
+	Policies   []admxPolicy   `xml:"policies>policy"`
+}
+
+type admxCategory struct {
+	Name           string `xml:"name,attr"`
+	DisplayName    string `xml:"displayName,attr"`
+	ParentCategory struct {
+		Ref string `xml:"ref,attr"`
+	} `xml:"parentCategory"`
+}
+
+type admxPolicy struct {
+	Name           string `xml:"name,attr"`
+	Class          string `xml:"class,attr"`
+	DisplayName    string `xml:"displayName,attr"`
+	ExplainText    string `xml:"explainText,attr"`
+	Key            string `xml:"key,attr"`
+	ValueName      string `xml:"valueName,attr"`
+	ParentCategory struct {
+		Ref string `xml:"ref,attr"`
+	} `xml:"parentCategory"`
+	EnabledValue  *admxValue     `xml:"enabledValue"`
+	DisabledValue *admxValue     `xml:"disabledValue"`
+	EnabledList   *admxValueList `xml:"enabledList"`
+	DisabledList  *admxValueList `xml:"disabledList"`
+	Elements      struct {
+		Items []admxElement `xml:",any"`
+	} `xml:"elements"`
+}
+
+type admxValue struct {
+	Decimal *struct {
+		Value string `xml:"value,attr"`
+	} `xml:"decimal"`
+	LongDecimal *struct {
+		Value string `xml:"value,attr"`
+	} `xml:"longDecimal"`
+	String *string   `xml:"string"`
+	Delete *struct{} `xml:"delete"`
+}
+
+type admxValueList struct {
+	DefaultKey string `xml:"defaultKey,attr"`
+	Items      []struct {
+		Key       string     `xml:"key,attr"`
+		ValueName string     `xml:"valueName,attr"`
+		Value     *admxValue `xml:"value"`
+	} `xml:"item"`
+}
+
+type admxElement struct {
+	XMLName   xml.Name
+	ID        string `xml:"id,attr"`
+	Key       string `xml:"key,attr"`
+	ValueName string `xml:"valueName,attr"`
+	Items     []struct {
+		DisplayName string     `xml:"displayName,attr"`
+		Value       *admxValue `xml:"value"`
+	} `xml:"item"`
+	TrueValue  *admxValue `xml:"trueValue"`
+	FalseValue *admxValue `xml:"falseValue"`
+}
+
+type admlFile struct {
+	Strings []struct {
+		ID   string `xml:"id,attr"`
+		Text string `xml:",chardata"`
+	} `xml:"resources>stringTable>string"`
+}
+
+type admxCategoryRef struct {
+	namespace string
+	category  admxCategory
+	strings   map[string]string
+	usings    map[string]string
+}
+
+// TODO support selecting ADML language other than en-US
+func (r *PolicyRepositoryImpl) LoadPolicyDefinitions(dir string) ([]*entities.Policy, error) {
+
+	admxPaths, err := filepath.Glob(filepath.Join(dir, "*.admx"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admx files: %w", err)
+	}
+	if len(admxPaths) == 0 {
+		return nil, fmt.Errorf("no admx files found in %s", dir)
+	}
+
+	type loadedAdmx struct {
+		admx    *admxFile
+		strings map[string]string
+		usings  map[string]string
+	}
+
+	loaded := make([]*loadedAdmx, 0, len(admxPaths))
+	categories := make(map[string]*admxCategoryRef)
+
+	for _, admxPath := range admxPaths {
+
+		admx, err := readAdmx(admxPath)
+		if err != nil {
+			log.Println("LoadPolicyDefinitions readAdmx failed", err.Error())
+			continue
+		}
+
+		strs, err := readAdml(dir, admxPath)
+		if err != nil {
+			log.Println("LoadPolicyDefinitions readAdml failed", err.Error())
+			strs = make(map[string]string)
+		}
+
+		usings := make(map[string]string)
+		for _, u := range admx.Namespaces.Using {
+			usings[u.Prefix] = u.Namespace
+		}
+		usings[admx.Namespaces.Target.Prefix] = admx.Namespaces.Target.Namespace
+
+		for _, c := range admx.Categories {
+			categories[admx.Namespaces.Target.Namespace+":"+c.Name] = &admxCategoryRef{
+				namespace: admx.Namespaces.Target.Namespace, category: c, strings: strs, usings: usings,
+			}
+		}
+
+		loaded = append(loaded, &loadedAdmx{admx: admx, strings: strs, usings: usings})
+	}
+
+	policies := make([]*entities.Policy, 0)
+
+	for _, l := range loaded {
+		target := l.admx.Namespaces.Target.Namespace
+		for _, p := range l.admx.Policies {
+			policies = append(policies, &entities.Policy{
+				Name:          p.Name,
+				Class:         p.Class,
+				DisplayName:   resolveAdmlString(p.DisplayName, l.strings),
+				Category:      resolveCategoryPath(p.ParentCategory.Ref, target, l.usings, categories),
+				ExplainText:   resolveAdmlString(p.ExplainText, l.strings),
+				Key:           p.Key,
+				ValueName:     p.ValueName,
+				EnabledValue:  toPolicyValue(p.EnabledValue),
+				DisabledValue: toPolicyValue(p.DisabledValue),
+				EnabledList:   toPolicyListItems(p.EnabledList, p.Key),
+				DisabledList:  toPolicyListItems(p.DisabledList, p.Key),
+				Elements:      toPolicyElements(p.Elements.Items, p.Key, l.strings),
+			})
+		}
+	}
+
+	return policies, nil
+}
+
+func readAdmx(path string) (*admxFile, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	admx := new(admxFile)
+	if err := xml.Unmarshal(data, admx); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return admx, nil
+}
+
+func readAdml(dir string, admxPath string) (map[string]string, error) {
+
+	admlName := strings.TrimSuffix(filepath.Base(admxPath), filepath.Ext(admxPath)) + ".adml"
+
+	admlPath := filepath.Join(dir, POLICY_DEFAULT_LANGUAGE, admlName)
+	if _, err := os.Stat(admlPath); err != nil {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*", admlName))
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no adml file found for %s", admxPath)
+		}
+		admlPath = matches[0]
+	}
+
+	data, err := os.ReadFile(admlPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", admlPath, err)
+	}
+
+	adml := new(admlFile)
+	if err := xml.Unmarshal(data, adml); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", admlPath, err)
+	}
+
+	strs := make(map[string]string, len(adml.Strings))
+	for _, s := range adml.Strings {
+		strs[s.ID] = strings.TrimSpace(s.Text)
+	}
+
+	return strs, nil
+}
+
+func resolveAdmlString(ref string, strs map[string]string) string {
+
+	if strings.HasPrefix(ref, "$(string.") && strings.HasSuffix(ref, ")") {
+		if s, ok := strs[ref[len("$(string."):len(ref)-1]]; ok {
+			return s
+		}
+	}
+	return ref
+}
+
+func resolveCategoryPath(ref string, namespace string, usings map[string]string, categories map[string]*admxCategoryRef) string {
+
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for ref != "" {
+
+		ns, name := namespace, ref
+		if i := strings.Index(ref, ":"); i >= 0 {
+			ns, name = usings[ref[:i]], ref[i+1:]
+		}
+
+		key := ns + ":" + name
+		c, ok := categories[key]
+		if !ok || seen[key] {
+			break
+		}
+		seen[key] = true
+
+		names = append([]string{resolveAdmlString(c.category.DisplayName, c.strings)}, names...)
+		ref, namespace, usings = c.category.ParentCategory.Ref, c.namespace, c.usings
+	}
+
+	return strings.Join(names, "\\")
+}
+
+func toPolicyValue(v *admxValue) *entities.PolicyValue {
+
+	switch {
+	case v == nil:
+		return nil
+	case v.Decimal != nil:
+		return &entities.PolicyValue{Kind: utils.STR_POLICY_VALUE_DECIMAL, Data: v.Decimal.Value}
+	case v.LongDecimal != nil:
+		return &entities.PolicyValue{Kind: utils.STR_POLICY_VALUE_DECIMAL, Data: v.LongDecimal.Value}
+	case v.String != nil:
+		return &entities.PolicyValue{Kind: utils.STR_POLICY_VALUE_STRING, Data: *v.String}
+	case v.Delete != nil:
+		return &entities.PolicyValue{Kind: utils.STR_POLICY_VALUE_DELETE}
+	default:
+		return nil
+	}
+}
+
+func toPolicyListItems(l *admxValueList, policyKey string) []*entities.PolicyListItem {
+
+	if l == nil {
+		return nil
+	}
+
+	defaultKey := policyKey
+	if l.DefaultKey != "" {
+		defaultKey = l.DefaultKey
+	}
+
+	items := make([]*entities.PolicyListItem, 0, len(l.Items))
+	for _, item := range l.Items {
+		key := defaultKey
+		if item.Key != "" {
+			key = item.Key
+		}
+		items = append(items, &entities.PolicyListItem{Key: key, ValueName: item.ValueName, Value: toPolicyValue(item.Value)})
+	}
+
+	return items
+}
+
+func toPolicyElements(elems []admxElement, policyKey string, strs map[string]string) []*entities.PolicyElement {
+
+	result := make([]*entities.PolicyElement, 0, len(elems))
+
+	for _, e := range elems {
+
+		key := policyKey
+		if e.Key != "" {
+			key = e.Key
+		}
+
+		elem := &entities.PolicyElement{
+			Kind: e.XMLName.Local, ID: e.ID, Key: key, ValueName: e.ValueName,
+			TrueValue: toPolicyValue(e.TrueValue), FalseValue: toPolicyValue(e.FalseValue),
+		}
+
+		for _, item := range e.Items {
+			elem.Items = append(elem.Items, &entities.PolicyEnumItem{
+				DisplayName: resolveAdmlString(item.DisplayName, strs),
+				Value:       toPolicyValue(item.Value),
+			})
+		}
+
+		result = append(result, elem)
+	}
+
+	return result
+}
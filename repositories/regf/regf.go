@@ -0,0 +1,415 @@
+// Package regf is a minimal read-only parser for the regf hive format, enough
+// to enumerate keys and values of hives copied from or mounted out of a
+// Windows installation. It has no Windows dependency so images can be read on
+// any platform.
+package regf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	HIVE_BASE_BLOCK_SIZE  int    = 0x1000
+	HIVE_BIG_DATA_MIN     uint32 = 16344
+	HIVE_MAX_LIST_DEPTH   int    = 8
+	HIVE_MAX_KEY_DEPTH    int    = 512
+	HIVE_KEY_COMP_NAME    uint16 = 0x0020
+	HIVE_VALUE_COMP_NAME  uint16 = 0x0001
+	HIVE_DATA_INLINE_MASK uint32 = 0x80000000
+)
+
+var hiveSignature = []byte("regf")
+
+// Hive is a hive file read into memory.
+type Hive struct {
+	data     []byte
+	minor    uint32
+	rootCell uint32
+}
+
+// Key is a key node; its subkey and value lists are read on demand.
+type Key struct {
+	h      *Hive
+	offset uint32
+
+	Name      string
+	LastWrite time.Time
+
+	subkeyCount   uint32
+	subkeysOffset uint32
+	valueCount    uint32
+	valuesOffset  uint32
+}
+
+// Value is a value with its raw data, Type being the REG_* type number.
+type Value struct {
+	Name string
+	Type uint32
+	Data []byte
+}
+
+// Open reads the hive file at path.
+func Open(path string) (*Hive, error) {
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hive %s: %w", path, err)
+	}
+
+	if len(raw) < HIVE_BASE_BLOCK_SIZE || !bytes.Equal(raw[:4], hiveSignature) {
+		return nil, fmt.Errorf("not a registry hive: %s", path)
+	}
+
+	return &Hive{
+		data:     raw[HIVE_BASE_BLOCK_SIZE:],
+		minor:    binary.LittleEndian.Uint32(raw[0x18:]),
+		rootCell: binary.LittleEndian.Uint32(raw[0x24:]),
+	}, nil
+}
+
+func (h *Hive) cell(offset uint32) ([]byte, error) {
+
+	off := int(offset)
+	if off < 0 || off+4 > len(h.data) {
+		return nil, fmt.Errorf("cell offset 0x%x out of range", offset)
+	}
+
+	size := int(int32(binary.LittleEndian.Uint32(h.data[off:])))
+	if size < 0 {
+		size = -size
+	}
+	if size < 4 || size > len(h.data)-off {
+		return nil, fmt.Errorf("cell at 0x%x has invalid size %d", offset, size)
+	}
+
+	return h.data[off+4 : off+size], nil
+}
+
+func (h *Hive) RootKey() (*Key, error) {
+
+	return h.key(h.rootCell)
+}
+
+func (h *Hive) key(offset uint32) (*Key, error) {
+
+	c, err := h.cell(offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c) < 0x4c || c[0] != 'n' || c[1] != 'k' {
+		return nil, fmt.Errorf("cell at 0x%x is not a key node", offset)
+	}
+
+	flags := binary.LittleEndian.Uint16(c[0x02:])
+	nameLen := int(binary.LittleEndian.Uint16(c[0x48:]))
+	if 0x4c+nameLen > len(c) {
+		return nil, fmt.Errorf("key node at 0x%x has invalid name length", offset)
+	}
+
+	return &Key{
+		h:             h,
+		offset:        offset,
+		Name:          decodeHiveName(c[0x4c:0x4c+nameLen], flags&HIVE_KEY_COMP_NAME != 0),
+		LastWrite:     utils.FiletimeToTime(binary.LittleEndian.Uint64(c[0x04:])),
+		subkeyCount:   binary.LittleEndian.Uint32(c[0x14:]),
+		subkeysOffset: binary.LittleEndian.Uint32(c[0x1c:]),
+		valueCount:    binary.LittleEndian.Uint32(c[0x24:]),
+		valuesOffset:  binary.LittleEndian.Uint32(c[0x28:]),
+	}, nil
+}
+
+func (k *Key) Subkeys() ([]*Key, error) {
+
+	if k.subkeyCount == 0 {
+		return nil, nil
+	}
+
+	offsets, err := k.h.subkeyOffsets(k.subkeysOffset, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*Key, 0, len(offsets))
+	for _, off := range offsets {
+		sub, err := k.h.key(off)
+		if err != nil {
+			return keys, err
+		}
+		keys = append(keys, sub)
+	}
+
+	return keys, nil
+}
+
+func (k *Key) Subkey(path string) *Key {
+
+	curr := k
+	for _, name := range strings.Split(path, "\\") {
+		subs, _ := curr.Subkeys()
+		var next *Key
+		for _, sub := range subs {
+			if strings.EqualFold(sub.Name, name) {
+				next = sub
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		curr = next
+	}
+
+	return curr
+}
+
+// KeySet records visited key nodes, so walks over a corrupt hive whose subkey
+// lists point back at an ancestor stop instead of recursing forever.
+type KeySet map[keyRef]bool
+
+type keyRef struct {
+	h      *Hive
+	offset uint32
+}
+
+// Add records k and reports whether it was not visited before.
+func (s KeySet) Add(k *Key) bool {
+
+	ref := keyRef{h: k.h, offset: k.offset}
+	if s[ref] {
+		return false
+	}
+	s[ref] = true
+	return true
+}
+
+// Walk calls visit for k and every key below it, depth first, with path
+// extended by each key's name. Keys reached a second time or nested deeper
+// than HIVE_MAX_KEY_DEPTH are skipped and, like unreadable subkey lists,
+// reported in the returned error.
+func (k *Key) Walk(path string, visit func(path string, key *Key)) error {
+
+	return k.walk(path, 0, make(KeySet), visit)
+}
+
+func (k *Key) walk(path string, depth int, seen KeySet, visit func(path string, key *Key)) error {
+
+	if depth > HIVE_MAX_KEY_DEPTH {
+		return fmt.Errorf("%s nested too deep", path)
+	}
+	if !seen.Add(k) {
+		return fmt.Errorf("%s is key node 0x%x visited before", path, k.offset)
+	}
+
+	visit(path, k)
+
+	var errs []error
+
+	subkeys, err := k.Subkeys()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", path, err))
+	}
+
+	for _, sub := range subkeys {
+		if err := sub.walk(path+"\\"+sub.Name, depth+1, seen, visit); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *Hive) subkeyOffsets(offset uint32, depth int) ([]uint32, error) {
+
+	if depth > HIVE_MAX_LIST_DEPTH {
+		return nil, fmt.Errorf("subkey list at 0x%x nested too deep", offset)
+	}
+
+	c, err := h.cell(offset)
+	if err != nil {
+		return nil, err
+	}
+	if len(c) < 4 {
+		return nil, fmt.Errorf("subkey list at 0x%x is truncated", offset)
+	}
+
+	count := int(binary.LittleEndian.Uint16(c[2:]))
+	sig := string(c[:2])
+
+	stride := 4
+	if sig == "lf" || sig == "lh" {
+		stride = 8
+	} else if sig != "li" && sig != "ri" {
+		return nil, fmt.Errorf("unknown subkey list signature %q at 0x%x", sig, offset)
+	}
+
+	if 4+count*stride > len(c) {
+		return nil, fmt.Errorf("subkey list at 0x%x is truncated", offset)
+	}
+
+	offsets := make([]uint32, 0, count)
+	for i := 0; i < count; i++ {
+		off := binary.LittleEndian.Uint32(c[4+i*stride:])
+		if sig == "ri" {
+			sub, err := h.subkeyOffsets(off, depth+1)
+			if err != nil {
+				return offsets, err
+			}
+			offsets = append(offsets, sub...)
+			continue
+		}
+		offsets = append(offsets, off)
+	}
+
+	return offsets, nil
+}
+
+func (k *Key) Values() ([]*Value, error) {
+
+	if k.valueCount == 0 {
+		return nil, nil
+	}
+
+	list, err := k.h.cell(k.valuesOffset)
+	if err != nil {
+		return nil, err
+	}
+	if int(k.valueCount)*4 > len(list) {
+		return nil, fmt.Errorf("value list of %s is truncated", k.Name)
+	}
+
+	values := make([]*Value, 0, k.valueCount)
+	for i := 0; i < int(k.valueCount); i++ {
+		val, err := k.h.value(binary.LittleEndian.Uint32(list[i*4:]))
+		if err != nil {
+			return values, err
+		}
+		values = append(values, val)
+	}
+
+	return values, nil
+}
+
+func (k *Key) Value(name string) *Value {
+
+	values, _ := k.Values()
+	for _, val := range values {
+		if strings.EqualFold(val.Name, name) {
+			return val
+		}
+	}
+	return nil
+}
+
+func (h *Hive) value(offset uint32) (*Value, error) {
+
+	c, err := h.cell(offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c) < 0x14 || c[0] != 'v' || c[1] != 'k' {
+		return nil, fmt.Errorf("cell at 0x%x is not a value", offset)
+	}
+
+	nameLen := int(binary.LittleEndian.Uint16(c[0x02:]))
+	dataSize := binary.LittleEndian.Uint32(c[0x04:])
+	dataOffset := binary.LittleEndian.Uint32(c[0x08:])
+	valType := binary.LittleEndian.Uint32(c[0x0c:])
+	flags := binary.LittleEndian.Uint16(c[0x10:])
+
+	if 0x14+nameLen > len(c) {
+		return nil, fmt.Errorf("value at 0x%x has invalid name length", offset)
+	}
+
+	val := &Value{
+		Name: decodeHiveName(c[0x14:0x14+nameLen], flags&HIVE_VALUE_COMP_NAME != 0),
+		Type: valType,
+	}
+
+	if dataSize&HIVE_DATA_INLINE_MASK != 0 {
+		size := dataSize &^ HIVE_DATA_INLINE_MASK
+		if size > 4 {
+			size = 4
+		}
+		val.Data = append([]byte(nil), c[0x08:0x08+size]...)
+		return val, nil
+	}
+
+	if dataSize == 0 {
+		return val, nil
+	}
+
+	data, err := h.cell(dataOffset)
+	if err != nil {
+		return val, nil
+	}
+
+	if dataSize > HIVE_BIG_DATA_MIN && h.minor >= 4 && len(data) >= 8 && data[0] == 'd' && data[1] == 'b' {
+		val.Data = h.bigData(data, dataSize)
+		return val, nil
+	}
+
+	if int(dataSize) < len(data) {
+		data = data[:dataSize]
+	}
+	val.Data = data
+
+	return val, nil
+}
+
+func (h *Hive) bigData(db []byte, size uint32) []byte {
+
+	count := int(binary.LittleEndian.Uint16(db[2:]))
+
+	// size comes from the value, never reserve more than the segments can hold
+	if limit := uint32(count) * HIVE_BIG_DATA_MIN; size > limit {
+		size = limit
+	}
+	if size > uint32(len(h.data)) {
+		size = uint32(len(h.data))
+	}
+
+	segments, err := h.cell(binary.LittleEndian.Uint32(db[4:]))
+	if err != nil || count*4 > len(segments) {
+		return nil
+	}
+
+	data := make([]byte, 0, size)
+	for i := 0; i < count && uint32(len(data)) < size; i++ {
+		seg, err := h.cell(binary.LittleEndian.Uint32(segments[i*4:]))
+		if err != nil {
+			break
+		}
+		if remain := int(size) - len(data); len(seg) > remain {
+			seg = seg[:remain]
+		}
+		if len(seg) > int(HIVE_BIG_DATA_MIN) {
+			seg = seg[:HIVE_BIG_DATA_MIN]
+		}
+		data = append(data, seg...)
+	}
+
+	return data
+}
+
+func decodeHiveName(b []byte, compressed bool) string {
+
+	if !compressed {
+		return utils.UTF16ToString(b)
+	}
+
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
@@ -0,0 +1,348 @@
+package regf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testdata/synthetic.hiv is a hand-built regf hive:
+//
+//	ROOT                  (Default) REG_SZ "r"
+//	  ri -> lf [Alpha, Beta], li [Gamma]
+//	  Alpha               Text REG_SZ "hello", Größe (UTF-16 name) inline DWORD 7
+//	    lh -> Child
+//	  Beta
+//	  Gamma               Inline inline DWORD 0x12345678, Short 3 inline bytes,
+//	                      Big 20000 bytes in a two segment db record
+const STR_TEST_HIVE string = "testdata/synthetic.hiv"
+
+func openTestHive(t *testing.T) *Hive {
+
+	t.Helper()
+	h, err := Open(STR_TEST_HIVE)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return h
+}
+
+func testHiveKey(t *testing.T, h *Hive, path string) *Key {
+
+	t.Helper()
+	root, err := h.RootKey()
+	if err != nil {
+		t.Fatalf("RootKey: %v", err)
+	}
+	if path == "" {
+		return root
+	}
+	k := root.Subkey(path)
+	if k == nil {
+		t.Fatalf("subkey %s not found", path)
+	}
+	return k
+}
+
+func TestHiveSubkeys(t *testing.T) {
+
+	h := openTestHive(t)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"", []string{"Alpha", "Beta", "Gamma"}},
+		{"Alpha", []string{"Child"}},
+		{"alpha\\CHILD", nil},
+		{"Beta", nil},
+	}
+
+	for _, tt := range tests {
+		subs, err := testHiveKey(t, h, tt.path).Subkeys()
+		if err != nil {
+			t.Errorf("%q: subkeys: %v", tt.path, err)
+			continue
+		}
+		names := make([]string, 0, len(subs))
+		for _, sub := range subs {
+			names = append(names, sub.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%q: subkeys = %v, want %v", tt.path, names, tt.want)
+		}
+	}
+
+	root := testHiveKey(t, h, "")
+	if want := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC); !root.LastWrite.Equal(want) {
+		t.Errorf("root last write = %v, want %v", root.LastWrite, want)
+	}
+	if root.Subkey("Alpha\\Missing") != nil {
+		t.Errorf("Subkey Alpha\\Missing found")
+	}
+}
+
+func TestHiveValues(t *testing.T) {
+
+	h := openTestHive(t)
+
+	big := make([]byte, 20000)
+	for i := range big {
+		big[i] = byte(i * 7)
+	}
+
+	tests := []struct {
+		path    string
+		name    string
+		valType uint32
+		data    []byte
+	}{
+		{"", "", 1, []byte("r\x00\x00\x00")},
+		{"Alpha", "Text", 1, []byte("h\x00e\x00l\x00l\x00o\x00\x00\x00")},
+		{"Alpha", "größe", 4, []byte{7, 0, 0, 0}},
+		{"Gamma", "Inline", 4, []byte{0x78, 0x56, 0x34, 0x12}},
+		{"Gamma", "Short", 3, []byte("abc")},
+		{"Gamma", "Big", 3, big},
+	}
+
+	for _, tt := range tests {
+		val := testHiveKey(t, h, tt.path).Value(tt.name)
+		if val == nil {
+			t.Errorf("%s\\%s: value not found", tt.path, tt.name)
+			continue
+		}
+		if val.Type != tt.valType {
+			t.Errorf("%s\\%s: type = %d, want %d", tt.path, tt.name, val.Type, tt.valType)
+		}
+		if !bytes.Equal(val.Data, tt.data) {
+			t.Errorf("%s\\%s: data = % x (%d bytes), want %d bytes", tt.path, tt.name, firstBytes(val.Data), len(val.Data), len(tt.data))
+		}
+	}
+
+	values, err := testHiveKey(t, h, "Gamma").Values()
+	if err != nil || len(values) != 3 {
+		t.Errorf("Gamma values = %d, %v, want 3", len(values), err)
+	}
+}
+
+func TestHiveBigDataSize(t *testing.T) {
+
+	h := openTestHive(t)
+	gamma := testHiveKey(t, h, "Gamma")
+
+	list, err := h.cell(gamma.valuesOffset)
+	if err != nil {
+		t.Fatalf("value list: %v", err)
+	}
+	var big uint32
+	for i := 0; i < int(gamma.valueCount); i++ {
+		if val, err := h.value(binary.LittleEndian.Uint32(list[i*4:])); err == nil && val.Name == "Big" {
+			big = binary.LittleEndian.Uint32(list[i*4:])
+		}
+	}
+	if big == 0 {
+		t.Fatalf("value Big not found")
+	}
+
+	// the db record has two segments, whatever size the value claims
+	for _, size := range []int32{20001, 0x40000, 0x7ffffff0} {
+		val, err := patchedHive(h, big, 4, size).value(big)
+		if err != nil {
+			t.Errorf("size %d: %v", size, err)
+			continue
+		}
+		if len(val.Data) < 20000 || len(val.Data) > int(size) || cap(val.Data) > 2*int(HIVE_BIG_DATA_MIN) {
+			t.Errorf("size %d: %d bytes, capacity %d", size, len(val.Data), cap(val.Data))
+		}
+	}
+}
+
+func TestHiveCorruptOffsets(t *testing.T) {
+
+	h := openTestHive(t)
+	alpha := testHiveKey(t, h, "Alpha")
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"root cell out of range", func() error {
+			_, err := (&Hive{data: h.data, minor: h.minor, rootCell: uint32(len(h.data))}).RootKey()
+			return err
+		}},
+		{"root cell at max offset", func() error {
+			_, err := (&Hive{data: h.data, minor: h.minor, rootCell: 0xffffffff}).RootKey()
+			return err
+		}},
+		{"key offset at a value", func() error {
+			list, _ := h.cell(alpha.valuesOffset)
+			_, err := h.key(binary.LittleEndian.Uint32(list))
+			return err
+		}},
+		{"subkey list out of range", func() error {
+			k := *alpha
+			k.subkeysOffset = 0x7ffffff0
+			_, err := k.Subkeys()
+			return err
+		}},
+		{"subkey list at a key node", func() error {
+			k := *alpha
+			k.subkeysOffset = h.rootCell
+			_, err := k.Subkeys()
+			return err
+		}},
+		{"subkey count beyond list", func() error {
+			_, err := patchedHive(h, alpha.subkeysOffset, 2, 0x100).subkeyOffsets(alpha.subkeysOffset, 0)
+			return err
+		}},
+		{"value list out of range", func() error {
+			k := *alpha
+			k.valuesOffset = 0x7ffffff0
+			_, err := k.Values()
+			return err
+		}},
+		{"value count beyond list", func() error {
+			k := *alpha
+			k.valueCount = 100
+			_, err := k.Values()
+			return err
+		}},
+		{"value offset at a key node", func() error {
+			_, err := h.value(h.rootCell)
+			return err
+		}},
+		{"cell size beyond hive", func() error {
+			_, err := patchedHive(h, h.rootCell, -4, 0x7fffffff).RootKey()
+			return err
+		}},
+		{"self referencing index root", func() error {
+			_, err := (&Hive{data: selfReferencingIndexRoot()}).subkeyOffsets(0, 0)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		var err error
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s: panicked: %v", tt.name, r)
+				}
+			}()
+			err = tt.run()
+		}()
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func TestHiveWalk(t *testing.T) {
+
+	h := openTestHive(t)
+	root := testHiveKey(t, h, "")
+	alpha := testHiveKey(t, h, "Alpha")
+
+	tests := []struct {
+		name    string
+		h       *Hive
+		want    []string
+		wantErr bool
+	}{
+		{"whole hive", h, []string{"ROOT", "ROOT\\Alpha", "ROOT\\Alpha\\Child", "ROOT\\Beta", "ROOT\\Gamma"}, false},
+		{"subkey is its parent", cyclicSubkey(h, alpha, alpha), []string{"ROOT", "ROOT\\Alpha", "ROOT\\Beta", "ROOT\\Gamma"}, true},
+		{"subkey is the root", cyclicSubkey(h, alpha, root), []string{"ROOT", "ROOT\\Alpha", "ROOT\\Beta", "ROOT\\Gamma"}, true},
+	}
+
+	for _, tt := range tests {
+
+		start, err := tt.h.RootKey()
+		if err != nil {
+			t.Fatalf("%s: RootKey: %v", tt.name, err)
+		}
+
+		var paths []string
+		err = start.Walk("ROOT", func(path string, key *Key) {
+			paths = append(paths, path)
+		})
+
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if strings.Join(paths, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: visited %v, want %v", tt.name, paths, tt.want)
+		}
+	}
+}
+
+func TestOpenHiveRejectsNonHives(t *testing.T) {
+
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"short", []byte("regf")},
+		{"bad signature", make([]byte, HIVE_BASE_BLOCK_SIZE*2)},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "_"))
+		if err := os.WriteFile(path, tt.data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(path); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+
+	if _, err := Open(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("missing file: no error")
+	}
+}
+
+// patchedHive returns a copy of h whose cell at offset has the field at rel
+// overwritten, rel 2 being a 16-bit count and -4 the cell size.
+func patchedHive(h *Hive, offset uint32, rel int, v int32) *Hive {
+
+	data := append([]byte(nil), h.data...)
+	i := int(offset) + 4 + rel
+	if rel == 2 {
+		binary.LittleEndian.PutUint16(data[i:], uint16(v))
+	} else {
+		binary.LittleEndian.PutUint32(data[i:], uint32(v))
+	}
+	return &Hive{data: data, minor: h.minor, rootCell: h.rootCell}
+}
+
+// selfReferencingIndexRoot is a single ri cell listing itself.
+func selfReferencingIndexRoot() []byte {
+
+	data := make([]byte, 16)
+	binary.LittleEndian.PutUint32(data[0:], uint32(0xfffffff0)) // -16
+	copy(data[4:], "ri")
+	binary.LittleEndian.PutUint16(data[6:], 1)
+	binary.LittleEndian.PutUint32(data[8:], 0)
+	return data
+}
+
+// cyclicSubkey returns a copy of h whose parent lists target, an ancestor or
+// the parent itself, as its first subkey.
+func cyclicSubkey(h *Hive, parent *Key, target *Key) *Hive {
+
+	return patchedHive(h, parent.subkeysOffset, 4, int32(target.offset))
+}
+
+func firstBytes(b []byte) []byte {
+
+	if len(b) > 16 {
+		return b[:16]
+	}
+	return b
+}
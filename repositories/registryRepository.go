@@ -12,24 +12,42 @@ import (
 	"golang.org/x/sys/windows/registry"
 )
 
+const (
+	REG_DEVPROP_MASK uint32 = 0xffff0000
+)
+
 type RegistryRepository interface {
 	StreamRegistry() <-chan *entities.Registry
 }
 
-type RegistryRepositoryImpl struct{}
+type RegistryRepositoryImpl struct {
+	dedupe bool
+}
 
 func NewRegistryRepository() *RegistryRepositoryImpl {
 	return &RegistryRepositoryImpl{}
 }
 
+// NewDedupedRegistryRepository scans each physical key once: HKCU, HKCR, HKCC
+// and CurrentControlSet are not walked and are instead reported as aliases of
+// the HKU, HKLM\SOFTWARE\Classes and ControlSet keys they resolve to.
+func NewDedupedRegistryRepository() *RegistryRepositoryImpl {
+	return &RegistryRepositoryImpl{dedupe: true}
+}
+
 // TODO logic/performance improving on all related to this
 func (r *RegistryRepositoryImpl) StreamRegistry() <-chan *entities.Registry {
 
-	genByHKCR := generateRegByKey(registry.CLASSES_ROOT)
-	genByHKCU := generateRegByKey(registry.CURRENT_USER)
-	genByHKLM := generateRegByKey(registry.LOCAL_MACHINE)
-	genByHKCC := generateRegByKey(registry.CURRENT_CONFIG)
-	genByHKU := generateRegByKey(registry.USERS)
+	if r.dedupe {
+		scan := newDedupedScan()
+		return fanInGenerate(generateRegByKey(registry.LOCAL_MACHINE, scan), generateRegByKey(registry.USERS, scan))
+	}
+
+	genByHKCR := generateRegByKey(registry.CLASSES_ROOT, nil)
+	genByHKCU := generateRegByKey(registry.CURRENT_USER, nil)
+	genByHKLM := generateRegByKey(registry.LOCAL_MACHINE, nil)
+	genByHKCC := generateRegByKey(registry.CURRENT_CONFIG, nil)
+	genByHKU := generateRegByKey(registry.USERS, nil)
 
 	stream := fanInGenerate(genByHKCR, genByHKCU, genByHKLM, genByHKCC, genByHKU)
 
@@ -47,6 +65,7 @@ func fanInGenerate(genChans ...<-chan *entities.Registry) <-chan *entities.Regis
 			reg, ok := <-gen
 			if !ok {
 				gen = nil
+				continue
 			}
 			streamChan <- reg
 		}
@@ -65,7 +84,7 @@ func fanInGenerate(genChans ...<-chan *entities.Registry) <-chan *entities.Regis
 	return streamChan
 }
 
-func generateRegByKey(key registry.Key) <-chan *entities.Registry {
+func generateRegByKey(key registry.Key, scan *liveScan) <-chan *entities.Registry {
 
 	regChan := make(chan *entities.Registry)
 
@@ -75,12 +94,15 @@ func generateRegByKey(key registry.Key) <-chan *entities.Registry {
 		return nil
 	}
 
-	go queryEnumKeys(&hkey, utils.KeyToString(key), regChan)
+	go func() {
+		defer close(regChan)
+		queryEnumKeys(&hkey, utils.KeyToString(key), scan, regChan)
+	}()
 
 	return regChan
 }
 
-func queryEnumKeys(hkey *registry.Key, path string, regChan chan *entities.Registry) {
+func queryEnumKeys(hkey *registry.Key, path string, scan *liveScan, regChan chan *entities.Registry) {
 
 	_, err := hkey.Stat()
 	if err != nil {
@@ -92,24 +114,29 @@ func queryEnumKeys(hkey *registry.Key, path string, regChan chan *entities.Regis
 		return
 	}
 
-	queryEnumValues(hkey, path, regChan)
+	queryEnumValues(hkey, path, scan.aliasesOf(path), regChan)
 
 	for _, subkey := range subKeys {
+		if scan.skips(path + "\\" + subkey) {
+			continue
+		}
 		_hkey, _ := registry.OpenKey(*hkey, subkey, registry.READ)
-		queryEnumKeys(&_hkey, path+"\\"+subkey, regChan)
+		queryEnumKeys(&_hkey, path+"\\"+subkey, scan, regChan)
 	}
 
 }
 
-func queryEnumValues(hkey *registry.Key, path string, regChan chan *entities.Registry) {
+func queryEnumValues(hkey *registry.Key, path string, aliases []string, regChan chan *entities.Registry) {
 
 	hkeyStat, err := hkey.Stat()
 	if err != nil {
 		log.Println("queryEnumValues Stat() failed", err.Error())
 	}
 
+	lastWrite := hkeyStat.ModTime().UTC()
+
 	if hkeyStat.ValueCount == 0 {
-		regChan <- &entities.Registry{Path: path, Name: "", Type: "", Value: ""}
+		regChan <- &entities.Registry{Path: path, Name: "", Type: "", Value: "", Aliases: aliases, LastWrite: lastWrite}
 		return
 	}
 
@@ -120,39 +147,49 @@ func queryEnumValues(hkey *registry.Key, path string, regChan chan *entities.Reg
 		return
 	}
 
-	regChan <- &entities.Registry{Path: path, Name: "", Type: "", Value: ""}
+	regChan <- &entities.Registry{Path: path, Name: "", Type: "", Value: "", Aliases: aliases, LastWrite: lastWrite}
 
 	for _, name := range valNames {
 
-		val, valType, err := queryValue(hkey, name)
+		val, valType, strs, err := queryValue(hkey, name)
 		if err != nil {
 			log.Println("QueryEnumValues queryValue failed", err.Error())
 			return
 		}
 
-		regChan <- &entities.Registry{Path: path, Name: name, Type: valType, Value: val}
+		regChan <- &entities.Registry{Path: path, Name: name, Type: valType, Value: val, Strings: strs, Aliases: aliases, LastWrite: lastWrite}
 	}
 
 }
 
-func queryValue(hkey *registry.Key, name string) (string, string, error) {
+func queryValue(hkey *registry.Key, name string) (string, string, []string, error) {
 
 	n, valType, err := hkey.GetValue(name, nil)
 	if err != nil {
 		if err == registry.ErrNotExist {
-			return "", "", fmt.Errorf("value does not exist: %w", err)
+			return "", "", nil, fmt.Errorf("value does not exist: %w", err)
 		}
-		return "", "", fmt.Errorf("failed to get value info: %w", err)
+		return "", "", nil, fmt.Errorf("failed to get value info: %w", err)
 	}
 
 	buf := make([]byte, n)
 	_, _, err = hkey.GetValue(name, buf)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to get value data: %w", err)
+		return "", "", nil, fmt.Errorf("failed to get value data: %w", err)
 	}
 
+	strValue, typeStr, strs := formatValue(buf, valType)
+
+	return strValue, typeStr, strs, nil
+}
+
+// formatValue renders value data for display, returning the separate strings
+// of a REG_MULTI_SZ as well.
+func formatValue(buf []byte, valType uint32) (string, string, []string) {
+
 	var strValue string
 	var typeStr string
+	var strs []string
 
 	switch valType {
 	case registry.NONE:
@@ -179,12 +216,20 @@ func queryValue(hkey *registry.Key, name string) (string, string, error) {
 		}
 		typeStr = utils.STR_REG_QWORD
 	case registry.MULTI_SZ:
-		strValue = strings.Join(utils.MultiSZToStringSlice(buf), ", ")
+		strs = utils.MultiSZToStringSlice(buf)
+		strValue = strings.Join(strs, ", ")
 		typeStr = utils.STR_REG_MULTI_SZ
 	default:
-		strValue = string(buf)
-		typeStr = utils.GetTypeString(valType)
+		// device properties under Enum\...\Properties carry their DEVPROPTYPE
+		// in the low word, e.g. 0xffff0010 for a FILETIME
+		if valType&REG_DEVPROP_MASK == REG_DEVPROP_MASK {
+			strValue = fmt.Sprintf("%x", buf)
+			typeStr = utils.STR_REG_DEVPROP
+		} else {
+			strValue = string(buf)
+			typeStr = utils.GetTypeString(valType)
+		}
 	}
 
-	return strValue, typeStr, nil
+	return strValue, typeStr, strs
 }
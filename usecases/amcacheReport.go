@@ -0,0 +1,201 @@
+package usecases
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_AMCACHE string = "Amcache"
+
+	AMCACHE_LINK_DATE_LAYOUT string = "01/02/2006 15:04:05"
+	AMCACHE_SHA1_PREFIX      string = "0000"
+	AMCACHE_SHA1_LENGTH      int    = 40
+
+	STR_AMCACHE_FILE     string = "File"
+	STR_AMCACHE_PROGRAM  string = "Program"
+	STR_AMCACHE_DRIVER   string = "Driver"
+	STR_AMCACHE_SHORTCUT string = "Shortcut"
+)
+
+var amcacheReportColumns = []string{"Category", "Path", "Name", "SHA1", "Program", "Product", "Publisher", "Version", "Link Date", "Key Last Write", "Registry Key"}
+
+type amcacheEntry struct {
+	category  string
+	path      string
+	name      string
+	sha1      string
+	program   string
+	product   string
+	publisher string
+	version   string
+	linkDate  string
+}
+
+// AmcacheReport correlates the inventory keys of Amcache.hve into one row per
+// file, program, driver or shortcut. Both the Windows 10 Inventory* layout and
+// the older Root\File and Root\Programs layout are read.
+func (u *RegistryUsecaseImpl) AmcacheReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_AMCACHE, Columns: amcacheReportColumns}
+
+	idx := newKeyIndex(regs, func(path string) bool {
+		return strings.Contains(strings.ToLower(path), "\\root\\")
+	})
+
+	roots := make([]string, 0)
+	for _, k := range idx.keys {
+		if strings.EqualFold(k.name(), "Root") && (k.child("InventoryApplicationFile") != nil || k.child("File") != nil) {
+			roots = append(roots, k.path)
+		}
+	}
+	sort.Strings(roots)
+
+	for _, path := range roots {
+		root := idx.get(path)
+		appendInventoryRows(root, report)
+		appendLegacyAmcacheRows(root, report)
+	}
+
+	return report
+}
+
+func appendInventoryRows(root *indexedKey, report *entities.Report) {
+
+	programs := make(map[string]string)
+	if apps := root.child("InventoryApplication"); apps != nil {
+		for _, k := range apps.children {
+			programs[strings.ToLower(k.name())] = k.valueString("Name")
+			appendAmcacheRow(report, k, &amcacheEntry{
+				category:  STR_AMCACHE_PROGRAM,
+				path:      k.valueString("RootDirPath"),
+				name:      k.valueString("Name"),
+				publisher: k.valueString("Publisher"),
+				version:   k.valueString("Version"),
+			})
+		}
+	}
+
+	if files := root.child("InventoryApplicationFile"); files != nil {
+		for _, k := range files.children {
+			appendAmcacheRow(report, k, &amcacheEntry{
+				category:  STR_AMCACHE_FILE,
+				path:      k.valueString("LowerCaseLongPath"),
+				name:      k.valueString("Name"),
+				sha1:      amcacheSHA1(k.valueString("FileId")),
+				program:   programs[strings.ToLower(k.valueString("ProgramId"))],
+				product:   k.valueString("ProductName"),
+				publisher: k.valueString("Publisher"),
+				version:   k.valueString("Version"),
+				linkDate:  amcacheLinkDate(k.valueString("LinkDate")),
+			})
+		}
+	}
+
+	if drivers := root.child("InventoryDriverBinary"); drivers != nil {
+		for _, k := range drivers.children {
+			appendAmcacheRow(report, k, &amcacheEntry{
+				category:  STR_AMCACHE_DRIVER,
+				path:      strings.ReplaceAll(k.name(), "/", "\\"),
+				name:      k.valueString("DriverName"),
+				sha1:      amcacheSHA1(k.valueString("DriverId")),
+				product:   k.valueString("Product"),
+				publisher: k.valueString("DriverCompany"),
+				version:   k.valueString("DriverVersion"),
+				linkDate:  amcacheUnixTime(k.valueString("DriverTimeStamp")),
+			})
+		}
+	}
+
+	if shortcuts := root.child("InventoryApplicationShortcut"); shortcuts != nil {
+		for _, k := range shortcuts.children {
+			path := k.valueString("ShortcutPath")
+			appendAmcacheRow(report, k, &amcacheEntry{
+				category: STR_AMCACHE_SHORTCUT,
+				path:     path,
+				name:     path[strings.LastIndex(path, "\\")+1:],
+			})
+		}
+	}
+}
+
+// appendLegacyAmcacheRows reads the Windows 8 and early Windows 10 layout,
+// whose values are named by number: File\{volume}\{file reference} and
+// Programs\{program id}.
+func appendLegacyAmcacheRows(root *indexedKey, report *entities.Report) {
+
+	programs := make(map[string]string)
+	if apps := root.child("Programs"); apps != nil {
+		for _, k := range apps.children {
+			programs[strings.ToLower(k.name())] = k.valueString("0")
+			appendAmcacheRow(report, k, &amcacheEntry{
+				category:  STR_AMCACHE_PROGRAM,
+				name:      k.valueString("0"),
+				version:   k.valueString("1"),
+				publisher: k.valueString("2"),
+			})
+		}
+	}
+
+	volumes := root.child("File")
+	if volumes == nil {
+		return
+	}
+
+	for _, volume := range volumes.children {
+		for _, k := range volume.children {
+			path := k.valueString("15")
+			appendAmcacheRow(report, k, &amcacheEntry{
+				category:  STR_AMCACHE_FILE,
+				path:      path,
+				name:      path[strings.LastIndex(path, "\\")+1:],
+				sha1:      amcacheSHA1(k.valueString("101")),
+				program:   programs[strings.ToLower(k.valueString("100"))],
+				product:   k.valueString("0"),
+				publisher: k.valueString("1"),
+				version:   k.valueString("5"),
+				linkDate:  amcacheUnixTime(k.valueString("f")),
+			})
+		}
+	}
+}
+
+func appendAmcacheRow(report *entities.Report, k *indexedKey, e *amcacheEntry) {
+
+	report.Rows = append(report.Rows, []string{
+		e.category, e.path, e.name, e.sha1, e.program, e.product, e.publisher, e.version, e.linkDate,
+		utils.FormatTime(k.lastWrite()), k.path,
+	})
+}
+
+// amcacheSHA1 strips the four zero padding the hive stores before the hash.
+func amcacheSHA1(id string) string {
+
+	if len(id) == len(AMCACHE_SHA1_PREFIX)+AMCACHE_SHA1_LENGTH && strings.HasPrefix(id, AMCACHE_SHA1_PREFIX) {
+		return id[len(AMCACHE_SHA1_PREFIX):]
+	}
+	return id
+}
+
+func amcacheLinkDate(s string) string {
+
+	t, err := time.Parse(AMCACHE_LINK_DATE_LAYOUT, s)
+	if err != nil {
+		return s
+	}
+	return utils.FormatTime(t)
+}
+
+func amcacheUnixTime(s string) string {
+
+	secs, err := strconv.ParseUint(s, 0, 64)
+	if err != nil || secs == 0 {
+		return ""
+	}
+	return utils.FormatTime(time.Unix(int64(secs), 0))
+}
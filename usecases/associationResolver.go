@@ -0,0 +1,301 @@
+package usecases
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_ASSOCIATION  string = "File Association"
+	STR_REPORT_ASSOCIATIONS string = "Association Overrides"
+
+	STR_FILE_EXTS string = "Software\\Microsoft\\Windows\\CurrentVersion\\Explorer\\FileExts"
+
+	STR_ASSOC_QUERY        string = "Query"
+	STR_ASSOC_USER_CHOICE  string = "UserChoice"
+	STR_ASSOC_OPEN_WITH    string = "OpenWithProgids"
+	STR_ASSOC_APPLICATIONS string = "Applications"
+	STR_ASSOC_DEFAULT_VERB string = "open"
+)
+
+var (
+	associationColumns         = []string{"User", "Verb", "Command", "ProgID", "Selected By", "Command From", "Effective", "Registry Key"}
+	associationOverrideColumns = []string{"User", "Extension", "Machine ProgID", "Machine Command", "Effective ProgID", "Effective Command", "Selected By", "Key Last Write", "Registry Key"}
+)
+
+// associationIndex adds the Explorer FileExts choices to the classes.
+type associationIndex struct {
+	*classesIndex
+}
+
+// associationCandidate is a ProgID an extension may open with and what
+// selected it.
+type associationCandidate struct {
+	progID   string
+	source   string
+	key      *indexedKey
+	openWith bool
+}
+
+// associationVerb is a shell verb command found for a ProgID in one hive.
+type associationVerb struct {
+	verb    string
+	command string
+	source  string
+	key     *indexedKey
+	wins    bool
+}
+
+func newAssociationIndex(regs []*entities.Registry) *associationIndex {
+
+	return &associationIndex{newClassesIndex(regs, isAssociationClassKey, func(rel string) bool {
+		return utils.HasPrefixFold(rel, STR_FILE_EXTS+"\\")
+	})}
+}
+
+// isAssociationClassKey keeps extension keys and the ProgID keys with their
+// CurVer and shell subkeys, leaving CLSID and friends out of the index.
+// Applications\<exe> keys are ProgIDs too: "Open with" > "Always" writes them
+// to UserChoice.
+func isAssociationClassKey(rel string) bool {
+
+	segments := strings.Split(rel, "\\")
+	if strings.HasPrefix(segments[0], ".") || len(segments) == 1 {
+		return true
+	}
+	if strings.EqualFold(segments[0], STR_ASSOC_APPLICATIONS) {
+		segments = segments[1:]
+		if len(segments) == 1 {
+			return true
+		}
+	}
+	return strings.EqualFold(segments[1], "shell") || strings.EqualFold(segments[1], "CurVer")
+}
+
+// followCurVer replaces a version independent ProgID by the one CurVer names.
+func (a *associationIndex) followCurVer(user string, progID string) string {
+
+	if k, _ := a.classKey(user, progID+"\\CurVer"); k != nil {
+		if cur := k.valueString(""); cur != "" {
+			if target, _ := a.classKey(user, cur); target != nil {
+				return cur
+			}
+		}
+	}
+	return progID
+}
+
+// candidates lists the ProgIDs an extension opens with for user in order of
+// precedence, followed by the OpenWithProgids alternatives.
+func (a *associationIndex) candidates(user string, ext string) []*associationCandidate {
+
+	result := make([]*associationCandidate, 0)
+
+	if k := a.userKey(user, STR_FILE_EXTS+"\\"+ext+"\\UserChoice"); k != nil && k.valueString("ProgId") != "" {
+		result = append(result, &associationCandidate{progID: k.valueString("ProgId"), source: STR_ASSOC_USER_CHOICE, key: k})
+	}
+	if k := a.userKey(user, STR_SOFTWARE_CLASSES+"\\"+ext); k != nil && k.valueString("") != "" {
+		result = append(result, &associationCandidate{progID: k.valueString(""), source: STR_SOURCE_USER_CLASSES, key: k})
+	}
+	if k := a.machineKey(ext); k != nil && k.valueString("") != "" {
+		result = append(result, &associationCandidate{progID: k.valueString(""), source: STR_SOURCE_MACHINE_CLASSES, key: k})
+	}
+
+	for _, k := range []*indexedKey{
+		a.userKey(user, STR_FILE_EXTS+"\\"+ext+"\\OpenWithProgids"),
+		a.userKey(user, STR_SOFTWARE_CLASSES+"\\"+ext+"\\OpenWithProgids"),
+		a.machineKey(ext + "\\OpenWithProgids"),
+	} {
+		if k == nil {
+			continue
+		}
+		for _, v := range k.values {
+			if v.Name != "" {
+				result = append(result, &associationCandidate{progID: v.Name, source: STR_ASSOC_OPEN_WITH, key: k, openWith: true})
+			}
+		}
+	}
+
+	return result
+}
+
+// effective returns the candidate Windows opens the extension with: the first
+// non OpenWithProgids one whose ProgID is registered.
+func (a *associationIndex) effective(user string, candidates []*associationCandidate) *associationCandidate {
+
+	for _, c := range candidates {
+		if c.openWith {
+			continue
+		}
+		if k, _ := a.classKey(user, c.progID); k != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// verbs collects the shell verbs of a ProgID from the user's and the machine
+// classes, marking the one HKCR shows for each verb, and the default verb.
+func (a *associationIndex) verbs(user string, progID string) ([]*associationVerb, string) {
+
+	result := make([]*associationVerb, 0)
+	won := make(map[string]bool)
+	defaultVerb := ""
+
+	for _, shell := range []struct {
+		key    *indexedKey
+		source string
+	}{
+		{a.userKey(user, STR_SOFTWARE_CLASSES+"\\"+progID+"\\shell"), STR_SOURCE_USER_CLASSES},
+		{a.machineKey(progID + "\\shell"), STR_SOURCE_MACHINE_CLASSES},
+	} {
+		if shell.key == nil {
+			continue
+		}
+		if defaultVerb == "" {
+			defaultVerb, _, _ = strings.Cut(shell.key.valueString(""), ",")
+		}
+		for _, verb := range shell.key.children {
+			command := ""
+			if c := verb.child("command"); c != nil {
+				command = c.valueString("")
+				if command == "" && c.valueString("DelegateExecute") != "" {
+					command = "DelegateExecute " + c.valueString("DelegateExecute")
+				}
+			}
+			name := strings.ToLower(verb.name())
+			result = append(result, &associationVerb{verb: verb.name(), command: command, source: shell.source, key: verb, wins: !won[name]})
+			won[name] = true
+		}
+	}
+
+	if defaultVerb == "" {
+		defaultVerb = STR_ASSOC_DEFAULT_VERB
+		if len(result) > 0 && !won[STR_ASSOC_DEFAULT_VERB] {
+			defaultVerb = result[0].verb
+		}
+	}
+
+	return result, defaultVerb
+}
+
+// defaultCommand is the command of the winning default verb.
+func (a *associationIndex) defaultCommand(user string, progID string) string {
+
+	verbs, defaultVerb := a.verbs(user, progID)
+	for _, v := range verbs {
+		if v.wins && strings.EqualFold(v.verb, defaultVerb) {
+			return v.command
+		}
+	}
+	return ""
+}
+
+// ResolveAssociation walks an extension (".txt") or ProgID to the shell verb
+// commands of the machine and of every user, marking those that take effect.
+func (u *RegistryUsecaseImpl) ResolveAssociation(regs []*entities.Registry, query string) *entities.Report {
+
+	query = strings.TrimSpace(query)
+	report := &entities.Report{Title: STR_REPORT_ASSOCIATION + ": " + query, Columns: associationColumns}
+	if query == "" {
+		return report
+	}
+
+	a := newAssociationIndex(regs)
+
+	for _, user := range a.scopes() {
+
+		candidates := []*associationCandidate{{progID: query, source: STR_ASSOC_QUERY}}
+		if strings.HasPrefix(query, ".") {
+			candidates = a.candidates(user, query)
+		}
+		effective := a.effective(user, candidates)
+
+		scope := STR_CLASSES_MACHINE_SCOPE
+		if user != "" {
+			scope = u.sids.display(user, utils.STR_SID_DISPLAY_ANNOTATE)
+		}
+
+		for _, c := range candidates {
+
+			progID := a.followCurVer(user, c.progID)
+			verbs, defaultVerb := a.verbs(user, progID)
+			if progID != c.progID {
+				progID = c.progID + " -> " + progID
+			}
+
+			if len(verbs) == 0 {
+				report.Rows = append(report.Rows, []string{scope, "", "", progID, c.source, "", "", c.key.path})
+				continue
+			}
+
+			for _, v := range verbs {
+				verb := v.verb
+				if strings.EqualFold(verb, defaultVerb) {
+					verb += " (default)"
+				}
+				mark := ""
+				if c == effective && v.wins {
+					mark = STR_CLASSES_EFFECTIVE
+				}
+				report.Rows = append(report.Rows, []string{scope, verb, v.command, progID, c.source, v.source, mark, v.key.path})
+			}
+		}
+	}
+
+	return report
+}
+
+// AssociationReport lists, per user, every extension whose effective default
+// command differs from the one the machine classes alone would give.
+func (u *RegistryUsecaseImpl) AssociationReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_ASSOCIATIONS, Columns: associationOverrideColumns}
+	a := newAssociationIndex(regs)
+
+	exts := make(map[string]string)
+	for _, k := range a.idx.keys {
+		if name := k.name(); strings.HasPrefix(name, ".") {
+			exts[strings.ToLower(name)] = name
+		}
+	}
+
+	sorted := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		sorted = append(sorted, ext)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return strings.ToLower(sorted[i]) < strings.ToLower(sorted[j]) })
+
+	for _, user := range a.users {
+		for _, ext := range sorted {
+
+			effective := a.effective(user, a.candidates(user, ext))
+			if effective == nil || effective.source == STR_SOURCE_MACHINE_CLASSES {
+				continue
+			}
+
+			machine := a.effective("", a.candidates("", ext))
+			machineProgID, machineCommand := "", ""
+			if machine != nil {
+				machineProgID = a.followCurVer("", machine.progID)
+				machineCommand = a.defaultCommand("", machineProgID)
+			}
+
+			progID := a.followCurVer(user, effective.progID)
+			command := a.defaultCommand(user, progID)
+			if strings.EqualFold(progID, machineProgID) && command == machineCommand {
+				continue
+			}
+
+			report.Rows = append(report.Rows, []string{
+				u.sids.display(user, utils.STR_SID_DISPLAY_ANNOTATE), ext, machineProgID, machineCommand, progID, command,
+				effective.source, utils.FormatTime(effective.key.lastWrite()), effective.key.path,
+			})
+		}
+	}
+
+	return report
+}
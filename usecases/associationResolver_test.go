@@ -0,0 +1,114 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_TEST_SID         string = "S-1-5-21-1-2-3-1001"
+	STR_TEST_NOTEPAD     string = "%SystemRoot%\\system32\\NOTEPAD.EXE %1"
+	STR_TEST_NOTEPADPP   string = "\"C:\\npp\\notepad++.exe\" \"%1\""
+	STR_TEST_APPLICATION string = "Applications\\notepad++.exe"
+)
+
+func TestIsAssociationClassKey(t *testing.T) {
+
+	tests := []struct {
+		rel  string
+		want bool
+	}{
+		{".txt", true},
+		{".txt\\OpenWithProgids", true},
+		{"txtfile", true},
+		{"txtfile\\shell\\open\\command", true},
+		{"txtfile\\CurVer", true},
+		{"txtfile\\DefaultIcon", false},
+		{"CLSID\\{00021401-0000-0000-C000-000000000046}", false},
+		{"Applications", true},
+		{"Applications\\notepad++.exe", true},
+		{"Applications\\notepad++.exe\\shell\\open\\command", true},
+		{"applications\\notepad++.exe\\CurVer", true},
+		{"Applications\\notepad++.exe\\SupportedTypes", false},
+	}
+
+	for _, tt := range tests {
+		if got := isAssociationClassKey(tt.rel); got != tt.want {
+			t.Errorf("isAssociationClassKey(%q) = %v, want %v", tt.rel, got, tt.want)
+		}
+	}
+}
+
+// applicationsUserChoice is a machine where .txt opens with Notepad and a user
+// who picked notepad++ through "Open with" > "Always".
+func applicationsUserChoice() []*entities.Registry {
+
+	return []*entities.Registry{
+		testValue(STR_MACHINE_CLASSES+"\\.txt", "", "txtfile"),
+		testValue(STR_MACHINE_CLASSES+"\\txtfile\\shell\\open\\command", "", STR_TEST_NOTEPAD),
+		testValue(utils.STR_HKEY_USERS+"\\"+STR_TEST_SID+"\\"+STR_FILE_EXTS+"\\.txt\\UserChoice", "ProgId", STR_TEST_APPLICATION),
+		testValue(utils.STR_HKEY_USERS+"\\"+STR_TEST_SID+STR_USER_CLASSES_SUFFIX+"\\"+STR_TEST_APPLICATION+"\\shell\\open\\command", "", STR_TEST_NOTEPADPP),
+	}
+}
+
+func TestResolveAssociationApplicationsUserChoice(t *testing.T) {
+
+	u := &RegistryUsecaseImpl{sids: newSidResolver(nil), guids: newGuidResolver()}
+	report := u.ResolveAssociation(applicationsUserChoice(), ".txt")
+
+	effective := make(map[string][]string)
+	for _, row := range report.Rows {
+		if row[6] == STR_CLASSES_EFFECTIVE {
+			if _, ok := effective[row[0]]; ok {
+				t.Errorf("%s has more than one effective row", row[0])
+			}
+			effective[row[0]] = row
+		}
+	}
+
+	tests := []struct {
+		scope   string
+		progID  string
+		command string
+		source  string
+	}{
+		{STR_CLASSES_MACHINE_SCOPE, "txtfile", STR_TEST_NOTEPAD, STR_SOURCE_MACHINE_CLASSES},
+		{u.sids.display(STR_TEST_SID, utils.STR_SID_DISPLAY_ANNOTATE), STR_TEST_APPLICATION, STR_TEST_NOTEPADPP, STR_ASSOC_USER_CHOICE},
+	}
+
+	for _, tt := range tests {
+		row, ok := effective[tt.scope]
+		if !ok {
+			t.Errorf("%s: no effective row in %v", tt.scope, report.Rows)
+			continue
+		}
+		if row[3] != tt.progID || row[2] != tt.command || row[4] != tt.source {
+			t.Errorf("%s: effective row = %v, want %s %s from %s", tt.scope, row, tt.progID, tt.command, tt.source)
+		}
+	}
+}
+
+func TestAssociationReportApplicationsUserChoice(t *testing.T) {
+
+	u := &RegistryUsecaseImpl{sids: newSidResolver(nil), guids: newGuidResolver()}
+	report := u.AssociationReport(applicationsUserChoice())
+
+	if len(report.Rows) != 1 {
+		t.Fatalf("rows = %v, want one override", report.Rows)
+	}
+
+	row := report.Rows[0]
+	want := []string{".txt", "txtfile", STR_TEST_NOTEPAD, STR_TEST_APPLICATION, STR_TEST_NOTEPADPP, STR_ASSOC_USER_CHOICE}
+	for i, w := range want {
+		if row[i+1] != w {
+			t.Errorf("column %s = %q, want %q", report.Columns[i+1], row[i+1], w)
+		}
+	}
+}
+
+func testValue(path string, name string, value string) *entities.Registry {
+
+	return &entities.Registry{Path: path, Name: name, Type: utils.STR_REG_SZ, Value: value}
+}
@@ -0,0 +1,49 @@
+package usecases
+
+import (
+	"sort"
+
+	"github.com/0736b/registry-finder-gui/decoders"
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_BAM string = "BAM/DAM"
+)
+
+var bamReportColumns = []string{"Last Run", "Source", "SID", "User", "Path", "Registry Key"}
+
+// BAMReport lists the executables recorded by the Background and Desktop
+// Activity Moderators, most recent first. Entries seen through more than one
+// control set are listed once.
+func (u *RegistryUsecaseImpl) BAMReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_BAM, Columns: bamReportColumns}
+	resolver := newSidResolver(regs)
+	seen := make(map[string]bool)
+
+	for _, reg := range regs {
+
+		if reg.Decoded == nil || (reg.Decoded.Decoder != decoders.DECODER_BAM && reg.Decoded.Decoder != decoders.DECODER_DAM) {
+			continue
+		}
+
+		sid := decoders.BAMSid(reg.Path)
+		k := reg.Decoded.Decoder + "|" + sid + "|" + reg.Name + "|" + reg.Value
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		report.Rows = append(report.Rows, []string{
+			utils.FormatTime(reg.Decoded.Events[0].Time), reg.Decoded.Decoder, sid, resolver.name(sid), reg.Name, reg.Path,
+		})
+	}
+
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		return report.Rows[i][0] > report.Rows[j][0]
+	})
+
+	return report
+}
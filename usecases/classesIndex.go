@@ -0,0 +1,105 @@
+package usecases
+
+import (
+	"sort"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_MACHINE_CLASSES string = utils.STR_HKEY_LOCAL_MACHINE + "\\SOFTWARE\\Classes"
+
+	STR_SOURCE_USER_CLASSES    string = "User Classes"
+	STR_SOURCE_MACHINE_CLASSES string = "Machine Classes"
+
+	STR_CLASSES_MACHINE_SCOPE string = "(Machine)"
+	STR_CLASSES_EFFECTIVE     string = "Yes"
+)
+
+// classesIndex answers HKCR style lookups for the machine and for every user
+// from the hives HKCR is merged from, so per user overrides stay visible.
+type classesIndex struct {
+	idx   *keyIndex
+	users []string
+}
+
+// newClassesIndex indexes the class keys keepClass accepts, given the path
+// relative to Classes, and the other user keys keepUser accepts, given the
+// path relative to the user hive. keepUser may be nil.
+func newClassesIndex(regs []*entities.Registry, keepClass func(rel string) bool, keepUser func(rel string) bool) *classesIndex {
+
+	c := &classesIndex{}
+	users := make(map[string]bool)
+
+	c.idx = newKeyIndex(regs, func(path string) bool {
+
+		if utils.HasPrefixFold(path, STR_MACHINE_CLASSES+"\\") {
+			return keepClass(path[len(STR_MACHINE_CLASSES)+1:])
+		}
+
+		user, rel, ok := splitUserPath(path)
+		if !ok {
+			return false
+		}
+		switch {
+		case utils.HasPrefixFold(rel, STR_SOFTWARE_CLASSES+"\\") && keepClass(rel[len(STR_SOFTWARE_CLASSES)+1:]):
+		case keepUser != nil && keepUser(rel):
+		default:
+			return false
+		}
+		users[user] = true
+		return true
+	})
+
+	for user := range users {
+		if user != utils.STR_HKEY_CURRENT_USER || len(users) == 1 {
+			c.users = append(c.users, user)
+		}
+	}
+	sort.Strings(c.users)
+
+	return c
+}
+
+// userKey finds rel under the user hive, looking for Software\Classes keys
+// in both of its views.
+func (c *classesIndex) userKey(user string, rel string) *indexedKey {
+
+	if user == "" {
+		return nil
+	}
+	if user == utils.STR_HKEY_CURRENT_USER {
+		return c.idx.get(utils.STR_HKEY_CURRENT_USER + "\\" + rel)
+	}
+	if utils.HasPrefixFold(rel, STR_SOFTWARE_CLASSES+"\\") {
+		if k := c.idx.get(utils.STR_HKEY_USERS + "\\" + user + STR_USER_CLASSES_SUFFIX + "\\" + rel[len(STR_SOFTWARE_CLASSES)+1:]); k != nil {
+			return k
+		}
+	}
+	return c.idx.get(utils.STR_HKEY_USERS + "\\" + user + "\\" + rel)
+}
+
+// machineKey finds rel under the machine's Classes.
+func (c *classesIndex) machineKey(rel string) *indexedKey {
+
+	return c.idx.get(STR_MACHINE_CLASSES + "\\" + rel)
+}
+
+// classKey resolves rel the way HKCR does, the user's classes first.
+func (c *classesIndex) classKey(user string, rel string) (*indexedKey, string) {
+
+	if k := c.userKey(user, STR_SOFTWARE_CLASSES+"\\"+rel); k != nil {
+		return k, STR_SOURCE_USER_CLASSES
+	}
+	if k := c.machineKey(rel); k != nil {
+		return k, STR_SOURCE_MACHINE_CLASSES
+	}
+	return nil, ""
+}
+
+// scopes lists the machine, as "", followed by every user.
+func (c *classesIndex) scopes() []string {
+
+	return append([]string{""}, c.users...)
+}
@@ -0,0 +1,255 @@
+package usecases
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_COM_OBJECT  string = "COM Object"
+	STR_REPORT_COM_HIJACKS string = "COM Hijacks"
+
+	STR_COM_VIEW_NATIVE string = "64-bit"
+	STR_COM_VIEW_WOW64  string = "32-bit"
+
+	STR_COM_FINDING_SHADOW string = "Shadows machine registration"
+)
+
+var (
+	comObjectColumns = []string{"User", "View", "Property", "Value", "Source", "Effective", "Key Last Write", "Registry Key"}
+	comHijackColumns = []string{"Finding", "User", "View", "CLSID", "Name", "Server", "Machine Server", "Key Last Write", "Registry Key"}
+
+	comViews = []struct {
+		prefix string
+		name   string
+	}{
+		{"", STR_COM_VIEW_NATIVE},
+		{"WOW6432Node\\", STR_COM_VIEW_WOW64},
+	}
+
+	// comProperties are read from the CLSID key (sub "") or one of its subkeys,
+	// value "" being the default value.
+	comProperties = []struct {
+		name  string
+		sub   string
+		value string
+	}{
+		{"Name", "", ""},
+		{"InprocServer32", "InprocServer32", ""},
+		{"ThreadingModel", "InprocServer32", "ThreadingModel"},
+		{"InprocHandler32", "InprocHandler32", ""},
+		{"LocalServer32", "LocalServer32", ""},
+		{"TreatAs", "TreatAs", ""},
+		{"ScriptletURL", "ScriptletURL", ""},
+		{"ProgID", "ProgID", ""},
+		{"VersionIndependentProgID", "VersionIndependentProgID", ""},
+		{"AppID", "", "AppID"},
+		{"TypeLib", "TypeLib", ""},
+	}
+
+	comAppIDProperties = []string{"LocalService", "RunAs", "DllSurrogate"}
+
+	// comServerKeys starts with the two that name a file on disk
+	comServerKeys = []string{"InprocServer32", "LocalServer32", "TreatAs", "ScriptletURL"}
+)
+
+func newComIndex(regs []*entities.Registry) *classesIndex {
+
+	return newClassesIndex(regs, isComClassKey, nil)
+}
+
+// isComClassKey keeps CLSID and AppID registrations in both views and the
+// CLSID subkey of ProgIDs, so a ProgID can stand in for its CLSID.
+func isComClassKey(rel string) bool {
+
+	segments := strings.Split(rel, "\\")
+	if strings.EqualFold(segments[0], "WOW6432Node") {
+		segments = segments[1:]
+	}
+	if len(segments) == 0 {
+		return true
+	}
+
+	switch {
+	case strings.EqualFold(segments[0], "CLSID"):
+		return len(segments) <= 3
+	case strings.EqualFold(segments[0], "AppID"):
+		return len(segments) <= 2
+	default:
+		return len(segments) == 1 || len(segments) == 2 && strings.EqualFold(segments[1], "CLSID")
+	}
+}
+
+// InspectCOMObject gathers the registration of a CLSID, or of the CLSID a
+// ProgID names, from the machine and every user in both registry views. The
+// rows HKCR would return are marked effective.
+func (u *RegistryUsecaseImpl) InspectCOMObject(regs []*entities.Registry, query string) *entities.Report {
+
+	query = strings.TrimSpace(query)
+	report := &entities.Report{Title: STR_REPORT_COM_OBJECT + ": " + query, Columns: comObjectColumns}
+	if query == "" {
+		return report
+	}
+
+	c := newComIndex(regs)
+
+	for _, user := range c.scopes() {
+
+		clsid := query
+		if !isBracedGUID(clsid) && isBracedGUID("{"+clsid+"}") {
+			clsid = "{" + clsid + "}"
+		}
+		if !isBracedGUID(clsid) {
+			k, _ := c.classKey(user, query+"\\CLSID")
+			if k == nil {
+				continue
+			}
+			clsid = k.valueString("")
+		}
+
+		scope := STR_CLASSES_MACHINE_SCOPE
+		if user != "" {
+			scope = u.sids.display(user, utils.STR_SID_DISPLAY_ANNOTATE)
+		}
+
+		for _, view := range comViews {
+
+			base := view.prefix + "CLSID\\" + clsid
+			if k, _ := c.classKey(user, base); k == nil {
+				continue
+			}
+
+			appID := ""
+			for _, p := range comProperties {
+				rel := base
+				if p.sub != "" {
+					rel += "\\" + p.sub
+				}
+				if value := u.appendComRows(report, c, user, scope, view.name, p.name, rel, p.value); p.name == "AppID" {
+					appID = value
+				}
+			}
+
+			if appID != "" {
+				for _, name := range comAppIDProperties {
+					u.appendComRows(report, c, user, scope, view.name, "AppID "+name, view.prefix+"AppID\\"+appID, name)
+				}
+			}
+		}
+	}
+
+	return report
+}
+
+// appendComRows adds the user's and the machine's value of one property and
+// returns the effective value.
+func (u *RegistryUsecaseImpl) appendComRows(report *entities.Report, c *classesIndex, user string, scope string, view string, property string, rel string, value string) string {
+
+	effective, _ := c.classKey(user, rel)
+	result := ""
+
+	for _, source := range []struct {
+		key  *indexedKey
+		name string
+	}{
+		{c.userKey(user, STR_SOFTWARE_CLASSES+"\\"+rel), STR_SOURCE_USER_CLASSES},
+		{c.machineKey(rel), STR_SOURCE_MACHINE_CLASSES},
+	} {
+		if source.key == nil || source.key.value(value) == nil {
+			continue
+		}
+
+		mark := ""
+		if source.key == effective {
+			mark = STR_CLASSES_EFFECTIVE
+			result = source.key.valueString(value)
+		}
+
+		report.Rows = append(report.Rows, []string{
+			scope, view, property, u.guids.annotate(source.key.valueString(value)), source.name, mark,
+			utils.FormatTime(source.key.lastWrite()), source.key.path,
+		})
+	}
+
+	return result
+}
+
+// COMHijackReport lists user CLSID registrations that shadow a machine one
+// and COM servers, of the machine or a user, in user-writable directories.
+func (u *RegistryUsecaseImpl) COMHijackReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_COM_HIJACKS, Columns: comHijackColumns}
+	c := newComIndex(regs)
+
+	for _, user := range c.scopes() {
+
+		scope := STR_CLASSES_MACHINE_SCOPE
+		if user != "" {
+			scope = u.sids.display(user, utils.STR_SID_DISPLAY_ANNOTATE)
+		}
+
+		for _, view := range comViews {
+
+			root := c.machineKey(view.prefix + "CLSID")
+			if user != "" {
+				root = c.userKey(user, STR_SOFTWARE_CLASSES+"\\"+view.prefix+"CLSID")
+			}
+			if root == nil {
+				continue
+			}
+
+			for _, k := range root.children {
+
+				server, serverKey := comServer(k)
+				if serverKey == nil {
+					continue
+				}
+
+				machine := c.machineKey(view.prefix + "CLSID\\" + k.name())
+				machineServer := ""
+				if machine != nil {
+					machineServer, _ = comServer(machine)
+				}
+
+				row := func(finding string, server string, key *indexedKey) []string {
+					return []string{
+						finding, scope, view.name, k.name(), u.guids.name(k.name()), server, machineServer,
+						utils.FormatTime(key.lastWrite()), key.path,
+					}
+				}
+
+				if user != "" && machine != nil {
+					report.Rows = append(report.Rows, row(STR_COM_FINDING_SHADOW, server, serverKey))
+				}
+
+				for _, name := range comServerKeys[:2] {
+					if sub := k.child(name); sub != nil {
+						if reason := utils.SuspiciousPathReason(utils.CommandBinary(sub.valueString(""))); reason != "" {
+							report.Rows = append(report.Rows, row(name+" in "+reason, sub.valueString(""), sub))
+						}
+					}
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		return report.Rows[i][0] < report.Rows[j][0]
+	})
+
+	return report
+}
+
+// comServer returns the first server a CLSID key registers and its subkey.
+func comServer(k *indexedKey) (string, *indexedKey) {
+
+	for _, name := range comServerKeys {
+		if sub := k.child(name); sub != nil {
+			return sub.valueString(""), sub
+		}
+	}
+	return "", nil
+}
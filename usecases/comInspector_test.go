@@ -0,0 +1,37 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+func TestCOMHijackReportServerPaths(t *testing.T) {
+
+	u := &RegistryUsecaseImpl{sids: newSidResolver(nil), guids: newGuidResolver()}
+	clsid := "\\CLSID\\{00000000-0000-0000-0000-000000000001}\\InprocServer32"
+
+	tests := []struct {
+		name   string
+		path   string
+		server string
+		want   []string
+	}{
+		{"system directory", STR_MACHINE_CLASSES + clsid, "C:\\Windows\\System32\\a.dll", nil},
+		{"machine server in a profile", STR_MACHINE_CLASSES + clsid, "C:\\Users\\bob\\Tools\\evil.dll", []string{"InprocServer32 in User profile"}},
+		{"user server in a profile", utils.STR_HKEY_USERS + "\\" + STR_TEST_SID + STR_USER_CLASSES_SUFFIX + clsid, "C:\\Users\\bob\\Tools\\evil.dll", []string{"InprocServer32 in User profile"}},
+		{"defender platform", STR_MACHINE_CLASSES + clsid, "C:\\ProgramData\\Microsoft\\Windows Defender\\Platform\\4.18.2302.7-0\\MpOav.dll", nil},
+	}
+
+	for _, tt := range tests {
+		report := u.COMHijackReport([]*entities.Registry{testValue(tt.path, "", tt.server)})
+		got := make([]string, 0)
+		for _, row := range report.Rows {
+			got = append(got, row[0])
+		}
+		if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+			t.Errorf("%s: findings %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
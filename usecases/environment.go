@@ -0,0 +1,247 @@
+package usecases
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_SESSION_ENVIRONMENT_PATTERN string = "HKEY_LOCAL_MACHINE\\SYSTEM\\*\\Control\\Session Manager\\Environment"
+	STR_WINDOWS_CURRENT_VERSION     string = utils.STR_HKEY_LOCAL_MACHINE + "\\SOFTWARE\\Microsoft\\Windows\\CurrentVersion"
+	STR_USER_ENVIRONMENT            string = "Environment"
+	STR_USER_VOLATILE_ENVIRONMENT   string = "Volatile Environment"
+
+	STR_ENV_SOURCE_DERIVED  string = "Derived"
+	STR_ENV_SOURCE_MACHINE  string = "Machine"
+	STR_ENV_SOURCE_VOLATILE string = "Volatile"
+	STR_ENV_SOURCE_USER     string = "User"
+	STR_ENV_SOURCE_MERGED   string = "Machine + User"
+
+	STR_ENV_PATH string = "Path"
+)
+
+var (
+	// machineEnvironmentDefaults are variables Windows sets from values outside
+	// Session Manager\Environment, given as key, value name and variable names.
+	machineEnvironmentDefaults = []struct {
+		key   string
+		value string
+		names []string
+	}{
+		{STR_WINDOWS_NT_CURRENT_VERSION, "SystemRoot", []string{"SystemRoot"}},
+		{STR_WINDOWS_CURRENT_VERSION, "ProgramFilesDir", []string{"ProgramFiles"}},
+		{STR_WINDOWS_CURRENT_VERSION, "ProgramFilesDir (x86)", []string{"ProgramFiles(x86)"}},
+		{STR_WINDOWS_CURRENT_VERSION, "ProgramW6432Dir", []string{"ProgramW6432"}},
+		{STR_WINDOWS_CURRENT_VERSION, "CommonFilesDir", []string{"CommonProgramFiles"}},
+		{STR_WINDOWS_CURRENT_VERSION, "CommonFilesDir (x86)", []string{"CommonProgramFiles(x86)"}},
+		{STR_WINDOWS_CURRENT_VERSION, "CommonW6432Dir", []string{"CommonProgramW6432"}},
+		{strings.TrimSuffix(STR_PROFILE_LIST, "\\"), "ProgramData", []string{"ProgramData", "ALLUSERSPROFILE"}},
+		{strings.TrimSuffix(STR_PROFILE_LIST, "\\"), "Public", []string{"PUBLIC"}},
+	}
+
+	// mergedEnvironmentVariables are appended to rather than replaced by the
+	// user's value.
+	mergedEnvironmentVariables = []string{"Path", "LibPath", "Os2LibPath"}
+)
+
+type envVar struct {
+	name   string
+	value  string
+	source string
+	key    string
+}
+
+// environment maps upper cased variable names to their definition.
+type environment map[string]*envVar
+
+func (e environment) set(name string, value string, source string, key string) {
+
+	e[strings.ToUpper(name)] = &envVar{name: name, value: value, source: source, key: key}
+}
+
+func (e environment) lookup(name string) (string, bool) {
+
+	if v, ok := e[strings.ToUpper(name)]; ok {
+		return v.value, true
+	}
+	return "", false
+}
+
+func (e environment) expand(s string) (string, error) {
+
+	return utils.ExpandEnvironment(s, e.lookup)
+}
+
+func (e environment) clone() environment {
+
+	c := make(environment, len(e))
+	for k, v := range e {
+		copied := *v
+		c[k] = &copied
+	}
+	return c
+}
+
+func (e environment) names() []string {
+
+	names := make([]string, 0, len(e))
+	for _, v := range e {
+		names = append(names, v.name)
+	}
+	sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+	return names
+}
+
+// environments rebuilds the machine environment and the effective one of
+// every user whose hive was scanned, the way the session manager and logon
+// compose them: derived defaults, the machine Environment key, then the
+// user's volatile and persistent Environment keys with Path appended.
+type environments struct {
+	machine environment
+	users   []string
+	perUser map[string]environment
+}
+
+func (u *RegistryUsecaseImpl) newEnvironments(regs []*entities.Registry) *environments {
+
+	idx := newKeyIndex(regs, isEnvironmentKey)
+
+	envs := &environments{machine: make(environment), perUser: make(map[string]environment)}
+
+	for _, d := range machineEnvironmentDefaults {
+		if k := idx.get(d.key); k != nil && k.valueString(d.value) != "" {
+			for _, name := range d.names {
+				envs.machine.set(name, k.valueString(d.value), STR_ENV_SOURCE_DERIVED, k.path)
+			}
+		}
+	}
+	if root, ok := envs.machine.lookup("SystemRoot"); ok && len(root) >= 2 {
+		envs.machine.set("SystemDrive", root[:2], STR_ENV_SOURCE_DERIVED, STR_WINDOWS_NT_CURRENT_VERSION)
+	}
+
+	if k := sessionEnvironmentKey(idx); k != nil {
+		for _, v := range k.values {
+			envs.machine.set(v.Name, v.Value, STR_ENV_SOURCE_MACHINE, k.path)
+		}
+	}
+
+	paths := make([]string, 0, len(idx.keys))
+	for _, k := range idx.keys {
+		paths = append(paths, k.path)
+	}
+	sort.Strings(paths)
+	deduper := newUserPathDeduper(paths)
+
+	type userKeys struct {
+		persistent *indexedKey
+		volatile   *indexedKey
+	}
+	keys := make(map[string]*userKeys)
+	for _, path := range paths {
+		user, rel, ok := splitUserPath(path)
+		if !ok {
+			continue
+		}
+		if _, keep := deduper.keep(path); !keep {
+			continue
+		}
+		if keys[user] == nil {
+			keys[user] = &userKeys{}
+			envs.users = append(envs.users, user)
+		}
+		if strings.EqualFold(rel, STR_USER_ENVIRONMENT) {
+			keys[user].persistent = idx.get(path)
+		} else {
+			keys[user].volatile = idx.get(path)
+		}
+	}
+
+	for _, user := range envs.users {
+
+		env := envs.machine.clone()
+
+		if profile := idx.get(STR_PROFILE_LIST + user); profile != nil && profile.valueString("ProfileImagePath") != "" {
+			home := profile.valueString("ProfileImagePath")
+			env.set("USERPROFILE", home, STR_ENV_SOURCE_DERIVED, profile.path)
+			env.set("APPDATA", home+"\\AppData\\Roaming", STR_ENV_SOURCE_DERIVED, profile.path)
+			env.set("LOCALAPPDATA", home+"\\AppData\\Local", STR_ENV_SOURCE_DERIVED, profile.path)
+			if len(home) > 2 && home[1] == ':' {
+				env.set("HOMEDRIVE", home[:2], STR_ENV_SOURCE_DERIVED, profile.path)
+				env.set("HOMEPATH", home[2:], STR_ENV_SOURCE_DERIVED, profile.path)
+			}
+		}
+		if name := u.sids.name(user); name != "" {
+			env.set("USERNAME", name, STR_ENV_SOURCE_DERIVED, "")
+		}
+
+		for _, k := range []*indexedKey{keys[user].volatile, keys[user].persistent} {
+			if k == nil {
+				continue
+			}
+			source := STR_ENV_SOURCE_USER
+			if k == keys[user].volatile {
+				source = STR_ENV_SOURCE_VOLATILE
+			}
+			for _, v := range k.values {
+				if machine, ok := envs.machine.lookup(v.Name); ok && isMergedEnvironmentVariable(v.Name) && k == keys[user].persistent {
+					env.set(v.Name, strings.TrimSuffix(machine, ";")+";"+v.Value, STR_ENV_SOURCE_MERGED, k.path)
+					continue
+				}
+				env.set(v.Name, v.Value, source, k.path)
+			}
+		}
+
+		envs.perUser[user] = env
+	}
+
+	return envs
+}
+
+// isEnvironmentKey keeps the keys the environment is composed from.
+func isEnvironmentKey(path string) bool {
+
+	if utils.MatchPathPattern(STR_SESSION_ENVIRONMENT_PATTERN, path) || strings.EqualFold(path, STR_WINDOWS_NT_CURRENT_VERSION) ||
+		strings.EqualFold(path, STR_WINDOWS_CURRENT_VERSION) || utils.HasPrefixFold(path, STR_PROFILE_LIST) ||
+		strings.EqualFold(path+"\\", STR_PROFILE_LIST) {
+		return true
+	}
+	_, rel, ok := splitUserPath(path)
+	return ok && (strings.EqualFold(rel, STR_USER_ENVIRONMENT) || strings.EqualFold(rel, STR_USER_VOLATILE_ENVIRONMENT))
+}
+
+// sessionEnvironmentKey picks the control set Windows boots from, the one
+// reached through CurrentControlSet, falling back to the first one.
+func sessionEnvironmentKey(idx *keyIndex) *indexedKey {
+
+	var first *indexedKey
+	for _, k := range idx.keys {
+		if !utils.MatchPathPattern(STR_SESSION_ENVIRONMENT_PATTERN, k.path) || len(k.values) == 0 {
+			continue
+		}
+		if strings.Contains(strings.ToLower(k.path), "currentcontrolset") {
+			return k
+		}
+		for _, alias := range k.values[0].Aliases {
+			if strings.Contains(strings.ToLower(alias), "currentcontrolset") {
+				return k
+			}
+		}
+		if first == nil || strings.ToLower(k.path) < strings.ToLower(first.path) {
+			first = k
+		}
+	}
+	return first
+}
+
+func isMergedEnvironmentVariable(name string) bool {
+
+	for _, merged := range mergedEnvironmentVariables {
+		if strings.EqualFold(merged, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package usecases
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_ENVIRONMENT string = "Environment"
+
+	STR_ENV_FINDING_RELATIVE   string = "Relative"
+	STR_ENV_FINDING_UNRESOLVED string = "Unresolved variable"
+	STR_ENV_FINDING_DUPLICATE  string = "Duplicate of #"
+	STR_ENV_FINDING_MISSING    string = "Missing"
+	STR_ENV_FINDING_WRITABLE   string = "User-writable"
+)
+
+var environmentReportColumns = []string{"User", "Variable", "Value", "Expanded", "Source", "Finding", "Registry Key"}
+
+// EnvironmentReport shows the effective environment of the machine and of
+// every user, with PATH split into one row per directory. Directories that are
+// relative, duplicated, missing or writable by users are flagged.
+func (u *RegistryUsecaseImpl) EnvironmentReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_ENVIRONMENT, Columns: environmentReportColumns}
+	envs := u.newEnvironments(regs)
+
+	report.Rows = append(report.Rows, u.environmentRows(STR_CLASSES_MACHINE_SCOPE, envs.machine)...)
+	for _, user := range envs.users {
+		report.Rows = append(report.Rows, u.environmentRows(u.sids.display(user, utils.STR_SID_DISPLAY_ANNOTATE), envs.perUser[user])...)
+	}
+
+	return report
+}
+
+func (u *RegistryUsecaseImpl) environmentRows(scope string, env environment) [][]string {
+
+	rows := make([][]string, 0, len(env))
+
+	for _, name := range env.names() {
+
+		v := env[strings.ToUpper(name)]
+		expanded, err := env.expand(v.value)
+		finding := ""
+		if err != nil {
+			finding = err.Error()
+		}
+		rows = append(rows, []string{scope, v.name, v.value, expanded, v.source, finding, v.key})
+
+		if strings.EqualFold(name, STR_ENV_PATH) {
+			rows = append(rows, u.pathRows(scope, env, v)...)
+		}
+	}
+
+	return rows
+}
+
+// pathRows splits a Path variable into its directories, expanding each one
+// and checking it against the live file system or the mounted image.
+func (u *RegistryUsecaseImpl) pathRows(scope string, env environment, v *envVar) [][]string {
+
+	rows := make([][]string, 0)
+	seen := make(map[string]int)
+	systemDrive, _ := env.lookup("SystemDrive")
+
+	for i, entry := range strings.Split(v.value, ";") {
+
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		expanded, err := env.expand(entry)
+		findings := make([]string, 0)
+		if err != nil {
+			findings = append(findings, err.Error())
+		}
+
+		dir := strings.TrimRight(strings.Trim(expanded, "\""), "\\")
+		key := strings.ToLower(dir)
+
+		switch {
+		case utils.HasUnexpandedVariable(dir):
+			findings = append(findings, STR_ENV_FINDING_UNRESOLVED)
+		case !isAbsoluteWindowsPath(dir):
+			findings = append(findings, STR_ENV_FINDING_RELATIVE)
+		default:
+			if exists, known := u.pathExists(dir, systemDrive); known && !exists {
+				findings = append(findings, STR_ENV_FINDING_MISSING)
+			}
+		}
+
+		if first, ok := seen[key]; ok {
+			findings = append(findings, STR_ENV_FINDING_DUPLICATE+strconv.Itoa(first))
+		} else {
+			seen[key] = i + 1
+		}
+
+		if reason := utils.SuspiciousPathReason(dir); reason != "" {
+			findings = append(findings, STR_ENV_FINDING_WRITABLE+" ("+reason+")")
+		}
+
+		rows = append(rows, []string{scope, v.name + " #" + strconv.Itoa(i+1), entry, expanded, v.source, strings.Join(findings, "; "), v.key})
+	}
+
+	return rows
+}
+
+// isAbsoluteWindowsPath accepts drive rooted (C:\...) and UNC paths.
+func isAbsoluteWindowsPath(path string) bool {
+
+	if strings.HasPrefix(path, "\\\\") {
+		return true
+	}
+	return len(path) >= 3 && path[1] == ':' && path[2] == '\\'
+}
+
+// pathExists checks a directory on the live system, or under the mounted image
+// root when reading offline hives. Offline, only paths on the image's system
+// drive can be checked, known is false for the others.
+func (u *RegistryUsecaseImpl) pathExists(path string, systemDrive string) (exists bool, known bool) {
+
+	if u.imageRoot == "" {
+		_, err := os.Stat(path)
+		return err == nil, true
+	}
+
+	if systemDrive == "" || !utils.HasPrefixFold(path, systemDrive) {
+		return false, false
+	}
+	_, err := os.Stat(filepath.Join(u.imageRoot, filepath.FromSlash(strings.ReplaceAll(path[len(systemDrive):], "\\", "/"))))
+	return err == nil, true
+}
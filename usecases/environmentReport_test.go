@@ -0,0 +1,38 @@
+package usecases
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+func TestEnvironmentReportWritablePath(t *testing.T) {
+
+	u := &RegistryUsecaseImpl{sids: newSidResolver(nil), guids: newGuidResolver(), expansion: newExpansion()}
+	session := utils.STR_HKEY_LOCAL_MACHINE + "\\SYSTEM\\ControlSet001\\Control\\Session Manager\\Environment"
+	path := "C:\\Windows\\System32;C:\\Users\\bob\\Tools;C:\\ProgramData\\Tool;C:\\ProgramData\\Microsoft\\Windows Defender\\Platform"
+
+	report := u.EnvironmentReport([]*entities.Registry{testValue(session, STR_ENV_PATH, path)})
+
+	want := map[string]string{
+		"C:\\Windows\\System32":                                  "",
+		"C:\\Users\\bob\\Tools":                                  STR_ENV_FINDING_WRITABLE + " (User profile)",
+		"C:\\ProgramData\\Tool":                                  STR_ENV_FINDING_WRITABLE + " (ProgramData)",
+		"C:\\ProgramData\\Microsoft\\Windows Defender\\Platform": "",
+	}
+	for _, row := range report.Rows {
+		w, ok := want[row[2]]
+		if !ok {
+			continue
+		}
+		delete(want, row[2])
+		if writable := strings.Contains(row[5], STR_ENV_FINDING_WRITABLE); writable != (w != "") || !strings.Contains(row[5], w) {
+			t.Errorf("%s: findings %q, want %q", row[2], row[5], w)
+		}
+	}
+	for dir := range want {
+		t.Errorf("%s: no row", dir)
+	}
+}
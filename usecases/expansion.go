@@ -0,0 +1,146 @@
+package usecases
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+// expansion holds the variables REG_EXPAND_SZ values are expanded with: those
+// of a user supplied file, else the Environment keys of an offline image or
+// of another user's hive as they are streamed, else the variables of this
+// process.
+type expansion struct {
+	mu      sync.Mutex
+	file    environment
+	learned []*entities.Registry
+	envs    *environments
+
+	// generation counts the Environment values learned and files loaded, so
+	// envs and expanded texts built before the last one are rebuilt.
+	generation     atomic.Uint64
+	envsGeneration uint64
+
+	textMu sync.Mutex
+	texts  map[*entities.Registry]*expandedText
+}
+
+type expandedText struct {
+	generation uint64
+	text       string
+}
+
+func newExpansion() *expansion {
+
+	return &expansion{texts: make(map[*entities.Registry]*expandedText)}
+}
+
+func (e *expansion) learn(reg *entities.Registry) {
+
+	if !isEnvironmentKey(reg.Path) {
+		return
+	}
+	e.mu.Lock()
+	e.learned = append(e.learned, reg)
+	e.generation.Add(1)
+	e.mu.Unlock()
+}
+
+// UseEnvironmentFile expands REG_EXPAND_SZ values with the NAME=VALUE lines of
+// the file at path instead of the live or offline environment.
+func (u *RegistryUsecaseImpl) UseEnvironmentFile(path string) {
+
+	if path == "" {
+		return
+	}
+
+	variables, err := u.environmentRepository.LoadEnvironment(path)
+	if err != nil {
+		log.Println("UseEnvironmentFile LoadEnvironment failed", err.Error())
+		return
+	}
+
+	file := make(environment, len(variables))
+	for name, value := range variables {
+		file.set(name, value, STR_ENV_SOURCE_USER, path)
+	}
+
+	u.expansion.mu.Lock()
+	u.expansion.file = file
+	u.expansion.generation.Add(1)
+	u.expansion.mu.Unlock()
+}
+
+// ExpandValue returns a REG_EXPAND_SZ value with its %NAME% references
+// expanded, or an empty string when there is nothing to expand. Values under
+// a user's hive use that user's variables, those of other users than the
+// current one are left unexpanded when none were read from their hive.
+func (u *RegistryUsecaseImpl) ExpandValue(reg *entities.Registry) string {
+
+	if reg.Type != utils.STR_REG_EXPAND_SZ || !strings.Contains(reg.Value, "%") {
+		return ""
+	}
+
+	generation := u.expansion.generation.Load()
+
+	u.expansion.textMu.Lock()
+	cached, ok := u.expansion.texts[reg]
+	u.expansion.textMu.Unlock()
+
+	if ok && cached.generation == generation {
+		return cached.text
+	}
+
+	expanded, _ := utils.ExpandEnvironment(reg.Value, u.expansionLookup(reg.Path))
+	if expanded == reg.Value {
+		expanded = ""
+	}
+
+	u.expansion.textMu.Lock()
+	u.expansion.texts[reg] = &expandedText{generation: generation, text: expanded}
+	u.expansion.textMu.Unlock()
+
+	return expanded
+}
+
+func (u *RegistryUsecaseImpl) expansionLookup(path string) func(name string) (string, bool) {
+
+	u.expansion.mu.Lock()
+	defer u.expansion.mu.Unlock()
+
+	if u.expansion.file != nil {
+		return u.expansion.file.lookup
+	}
+
+	user, _, isUser := splitUserPath(path)
+	if u.imageRoot == "" && (!isUser || user == utils.STR_HKEY_CURRENT_USER) {
+		return os.LookupEnv
+	}
+
+	if generation := u.expansion.generation.Load(); u.expansion.envs == nil || u.expansion.envsGeneration != generation {
+		u.expansion.envs = u.newEnvironments(u.expansion.learned)
+		u.expansion.envsGeneration = generation
+	}
+
+	if isUser {
+		if env, ok := u.expansion.envs.perUser[user]; ok {
+			return env.lookup
+		}
+	}
+	if u.imageRoot == "" {
+		return unknownEnvironment
+	}
+	return u.expansion.envs.machine.lookup
+}
+
+// unknownEnvironment leaves every variable unexpanded, for the hive of a live
+// user whose environment is neither this process' nor read yet.
+func unknownEnvironment(name string) (string, bool) {
+
+	return "", false
+}
@@ -0,0 +1,70 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const STR_TEST_ENV_VARIABLE string = "REGISTRY_FINDER_TEST_DIR"
+
+func TestExpandValueLive(t *testing.T) {
+
+	t.Setenv(STR_TEST_ENV_VARIABLE, "C:\\Process")
+
+	u := &RegistryUsecaseImpl{sids: newSidResolver(nil), guids: newGuidResolver(), expansion: newExpansion()}
+	user := utils.STR_HKEY_USERS + "\\" + STR_TEST_SID
+
+	machine := expandValue(utils.STR_HKEY_LOCAL_MACHINE+"\\SOFTWARE\\Test", "%"+STR_TEST_ENV_VARIABLE+"%\\a.exe")
+	current := expandValue(utils.STR_HKEY_CURRENT_USER+"\\Software\\Test", "%"+STR_TEST_ENV_VARIABLE+"%\\b.exe")
+	other := expandValue(user+"\\Software\\Test", "%"+STR_TEST_ENV_VARIABLE+"%\\c.exe")
+
+	tests := []struct {
+		name string
+		reg  *entities.Registry
+		want string
+	}{
+		{"machine value", machine, "C:\\Process\\a.exe"},
+		{"current user value", current, "C:\\Process\\b.exe"},
+		{"other user value", other, ""},
+		{"plain string", testValue(machine.Path, "Plain", machine.Value), ""},
+		{"nothing to expand", expandValue(machine.Path, "C:\\a.exe"), ""},
+	}
+
+	for _, tt := range tests {
+		if got := u.ExpandValue(tt.reg); got != tt.want {
+			t.Errorf("%s: ExpandValue(%q) = %q, want %q", tt.name, tt.reg.Value, got, tt.want)
+		}
+	}
+
+	u.expansion.learn(testValue(user+"\\"+STR_USER_ENVIRONMENT, STR_TEST_ENV_VARIABLE, "D:\\Other"))
+
+	if got, want := u.ExpandValue(other), "D:\\Other\\c.exe"; got != want {
+		t.Errorf("other user value after learning their environment = %q, want %q", got, want)
+	}
+	if got, want := u.ExpandValue(current), "C:\\Process\\b.exe"; got != want {
+		t.Errorf("current user value after learning another environment = %q, want %q", got, want)
+	}
+}
+
+func TestExpandValueOffline(t *testing.T) {
+
+	u := &RegistryUsecaseImpl{sids: newSidResolver(nil), guids: newGuidResolver(), expansion: newExpansion(), imageRoot: "E:\\"}
+	session := utils.STR_HKEY_LOCAL_MACHINE + "\\SYSTEM\\ControlSet001\\Control\\Session Manager\\Environment"
+	reg := expandValue(utils.STR_HKEY_LOCAL_MACHINE+"\\SOFTWARE\\Test", "%"+STR_TEST_ENV_VARIABLE+"%\\a.exe")
+
+	if got := u.ExpandValue(reg); got != "" {
+		t.Errorf("before the environment is read: %q, want nothing", got)
+	}
+
+	u.expansion.learn(testValue(session, STR_TEST_ENV_VARIABLE, "C:\\Image"))
+	if got, want := u.ExpandValue(reg), "C:\\Image\\a.exe"; got != want {
+		t.Errorf("after the environment is read: %q, want %q", got, want)
+	}
+}
+
+func expandValue(path string, value string) *entities.Registry {
+
+	return &entities.Registry{Path: path, Name: "Test", Type: utils.STR_REG_EXPAND_SZ, Value: value}
+}
@@ -0,0 +1,87 @@
+package usecases
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+var (
+	registryExportHeader = []string{"Path", "Aliases", "Origin", "Last Write", "Name", "Type", "Value", "Expanded", "Decoded", "Decoded Fields", "Policy", "Policy Category", "Policy Explanation", "Policy Value Meaning"}
+	timelineExportHeader = []string{"Time", "Source", "Path", "Name", "Description"}
+)
+
+func (u *RegistryUsecaseImpl) ExportRegistry(path string, regs []*entities.Registry) error {
+
+	rows := make([][]string, 0, len(regs))
+	for _, reg := range regs {
+		rows = append(rows, registryExportRow(reg, u.ExpandValue(reg)))
+	}
+
+	return writeCSV(path, registryExportHeader, rows)
+}
+
+func (u *RegistryUsecaseImpl) ExportTimeline(path string, events []*entities.TimelineEvent) error {
+
+	rows := make([][]string, 0, len(events))
+	for _, e := range events {
+		rows = append(rows, []string{utils.FormatTime(e.Time), e.Source, e.Path, e.Name, e.Description})
+	}
+
+	return writeCSV(path, timelineExportHeader, rows)
+}
+
+func writeCSV(path string, header []string, rows [][]string) error {
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write export header: %w", err)
+	}
+
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			return fmt.Errorf("failed to write export row: %w", err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to flush export file: %w", err)
+	}
+
+	return nil
+}
+
+func registryExportRow(reg *entities.Registry, expanded string) []string {
+
+	row := []string{reg.Path, strings.Join(reg.Aliases, "; "), reg.Origin, utils.FormatTime(reg.LastWrite), reg.Name, reg.Type, reg.Value, expanded, "", "", "", "", "", ""}
+
+	if reg.Decoded != nil {
+		fields := make([]string, len(reg.Decoded.Fields))
+		for i, f := range reg.Decoded.Fields {
+			fields[i] = f.Name + "=" + f.Value
+		}
+		row[8] = reg.Decoded.Summary
+		row[9] = strings.Join(fields, "; ")
+	}
+
+	if reg.Policy != nil {
+		row[10] = reg.Policy.DisplayName
+		row[11] = reg.Policy.Category
+		row[12] = reg.Policy.ExplainText
+		row[13] = reg.Policy.Meaning
+	}
+
+	return row
+}
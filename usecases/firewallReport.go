@@ -0,0 +1,70 @@
+package usecases
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/decoders"
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_FIREWALL string = "Firewall Rules"
+
+	STR_FIREWALL_LOCAL_PORTS  string = "Local Ports"
+	STR_FIREWALL_REMOTE_PORTS string = "Remote Ports"
+)
+
+var (
+	firewallReportColumns = []string{"Name", "Action", "Direction", "Active", "Protocol", STR_FIREWALL_LOCAL_PORTS, STR_FIREWALL_REMOTE_PORTS, "Remote Addresses", "Application", "Service", "Profiles", "Group", "Finding", "Rule ID", "Registry Key"}
+	firewallPortColumns   = []string{STR_FIREWALL_LOCAL_PORTS, STR_FIREWALL_REMOTE_PORTS}
+)
+
+// FirewallReport lists every firewall rule of every control set as structured
+// fields, once per rule id and definition. Active inbound allow rules for an
+// application in a user-writable directory are flagged.
+func (u *RegistryUsecaseImpl) FirewallReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_FIREWALL, Columns: firewallReportColumns, RangeColumns: firewallPortColumns}
+	seen := make(map[string]bool)
+
+	for _, reg := range regs {
+
+		if reg.Decoded == nil || reg.Decoded.Decoder != decoders.DECODER_FIREWALL_RULE {
+			continue
+		}
+
+		k := reg.Name + "|" + reg.Value
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		rule, err := decoders.ParseFirewallRule(reg.Value)
+		if err != nil {
+			continue
+		}
+
+		finding := ""
+		if rule.Active && strings.EqualFold(rule.Action, "Allow") && strings.EqualFold(rule.Direction, "In") {
+			if reason := utils.SuspiciousPathReason(rule.App); reason != "" {
+				finding = "Inbound allow to " + reason
+			}
+		}
+
+		report.Rows = append(report.Rows, []string{
+			rule.Name, rule.Action, rule.Direction, strconv.FormatBool(rule.Active), rule.Protocol,
+			strings.Join(rule.LocalPorts, ","), strings.Join(rule.RemotePorts, ","), strings.Join(rule.RemoteAddrs, ","),
+			rule.App, rule.Service, strings.Join(rule.Profiles, ","), rule.Group, finding, reg.Name, reg.Path,
+		})
+	}
+
+	// flagged rules first
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		return report.Rows[i][12] != "" && report.Rows[j][12] == ""
+	})
+
+	return report
+}
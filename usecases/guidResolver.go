@@ -0,0 +1,196 @@
+package usecases
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/0736b/registry-finder-gui/decoders"
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	GUID_BRACED_LENGTH int = 38
+)
+
+// guidResolver names the GUIDs registered under CLSID, Interface, TypeLib and
+// AppID in the scanned classes, falling back to the bundled shell and known
+// folder tables. Like sidResolver it learns while entities stream in.
+type guidResolver struct {
+	mu    sync.RWMutex
+	names map[string]string
+
+	// generation counts the names learned, so cached search texts built
+	// before the last one are rebuilt.
+	generation uint64
+
+	searchMu    sync.Mutex
+	searchTexts map[*entities.Registry]*guidSearchText
+}
+
+type guidSearchText struct {
+	generation uint64
+	text       string
+}
+
+func newGuidResolver() *guidResolver {
+
+	return &guidResolver{names: make(map[string]string), searchTexts: make(map[*entities.Registry]*guidSearchText)}
+}
+
+// learn records the friendly name held by the default value of a GUID key.
+// TypeLib names live one level down, in the version subkey.
+func (r *guidResolver) learn(reg *entities.Registry) {
+
+	if reg.Name != "" || reg.Type != utils.STR_REG_SZ || reg.Value == "" {
+		return
+	}
+
+	segments := strings.Split(reg.Path, "\\")
+	if len(segments) < 3 {
+		return
+	}
+
+	guid, parent := segments[len(segments)-1], segments[len(segments)-2]
+	if strings.EqualFold(segments[len(segments)-3], "TypeLib") {
+		guid, parent = parent, segments[len(segments)-3]
+	}
+
+	if !isBracedGUID(guid) {
+		return
+	}
+
+	switch strings.ToLower(parent) {
+	case "clsid", "interface", "typelib", "appid":
+	default:
+		return
+	}
+
+	guid = strings.ToUpper(guid)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.names[guid]; !ok {
+		r.names[guid] = reg.Value
+		r.generation++
+	}
+}
+
+func (r *guidResolver) name(guid string) string {
+
+	guid = strings.ToUpper(guid)
+
+	r.mu.RLock()
+	name, ok := r.names[guid]
+	r.mu.RUnlock()
+
+	if ok {
+		return name
+	}
+
+	name, _ = decoders.ShellFolderName(guid)
+	return name
+}
+
+// annotate appends the resolved name after every GUID in s.
+func (r *guidResolver) annotate(s string) string {
+
+	if !strings.Contains(s, "{") {
+		return s
+	}
+
+	var sb strings.Builder
+	for _, guid := range findGUIDs(s) {
+		i := strings.Index(s, guid)
+		sb.WriteString(s[:i+GUID_BRACED_LENGTH])
+		if name := r.name(guid); name != "" {
+			sb.WriteString(" (" + name + ")")
+		}
+		s = s[i+GUID_BRACED_LENGTH:]
+	}
+	sb.WriteString(s)
+
+	return sb.String()
+}
+
+// searchText joins the resolved names of the GUIDs in s for keyword matching.
+func (r *guidResolver) searchText(s string) string {
+
+	if !strings.Contains(s, "{") {
+		return ""
+	}
+
+	names := make([]string, 0)
+	for _, guid := range findGUIDs(s) {
+		if name := r.name(guid); name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// processedSearchText is the preprocessed searchText of the entity's path and
+// value, cached per entity until another GUID name is learned.
+func (r *guidResolver) processedSearchText(reg *entities.Registry) string {
+
+	if !strings.Contains(reg.Path, "{") && !strings.Contains(reg.Value, "{") {
+		return ""
+	}
+
+	r.mu.RLock()
+	generation := r.generation
+	r.mu.RUnlock()
+
+	r.searchMu.Lock()
+	cached, ok := r.searchTexts[reg]
+	r.searchMu.Unlock()
+
+	if ok && cached.generation == generation {
+		return cached.text
+	}
+
+	text := utils.PreProcessStr(r.searchText(reg.Path + reg.Value))
+
+	r.searchMu.Lock()
+	r.searchTexts[reg] = &guidSearchText{generation: generation, text: text}
+	r.searchMu.Unlock()
+
+	return text
+}
+
+// findGUIDs returns the braced GUIDs of s in order of appearance.
+func findGUIDs(s string) []string {
+
+	guids := make([]string, 0)
+	for i := 0; i+GUID_BRACED_LENGTH <= len(s); i++ {
+		if s[i] == '{' && isBracedGUID(s[i:i+GUID_BRACED_LENGTH]) {
+			guids = append(guids, s[i:i+GUID_BRACED_LENGTH])
+			i += GUID_BRACED_LENGTH - 1
+		}
+	}
+	return guids
+}
+
+func isBracedGUID(s string) bool {
+
+	if len(s) != GUID_BRACED_LENGTH || s[0] != '{' || s[GUID_BRACED_LENGTH-1] != '}' {
+		return false
+	}
+
+	for i := 1; i < GUID_BRACED_LENGTH-1; i++ {
+		c := s[i]
+		switch i {
+		case 9, 14, 19, 24:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
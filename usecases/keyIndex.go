@@ -0,0 +1,110 @@
+package usecases
+
+import (
+	"strings"
+	"time"
+
+	"github.com/0736b/registry-finder-gui/entities"
+)
+
+// keyIndex groups streamed entities back into keys so reports can walk the
+// tree and look up values by name.
+type keyIndex struct {
+	keys map[string]*indexedKey
+}
+
+type indexedKey struct {
+	path     string
+	key      *entities.Registry
+	values   []*entities.Registry
+	children []*indexedKey
+}
+
+// newKeyIndex indexes the entities whose key path satisfies match, nil matches all.
+func newKeyIndex(regs []*entities.Registry, match func(path string) bool) *keyIndex {
+
+	idx := &keyIndex{keys: make(map[string]*indexedKey)}
+
+	for _, reg := range regs {
+
+		if match != nil && !match(reg.Path) {
+			continue
+		}
+
+		k := idx.ensure(reg.Path)
+		if reg.Name == "" && reg.Type == "" {
+			k.key = reg
+		} else {
+			k.values = append(k.values, reg)
+		}
+	}
+
+	return idx
+}
+
+func (idx *keyIndex) ensure(path string) *indexedKey {
+
+	lower := strings.ToLower(path)
+	if k, ok := idx.keys[lower]; ok {
+		return k
+	}
+
+	k := &indexedKey{path: path}
+	idx.keys[lower] = k
+
+	if i := strings.LastIndex(path, "\\"); i > 0 {
+		parent := idx.ensure(path[:i])
+		parent.children = append(parent.children, k)
+	}
+
+	return k
+}
+
+func (idx *keyIndex) get(path string) *indexedKey {
+
+	return idx.keys[strings.ToLower(path)]
+}
+
+func (k *indexedKey) name() string {
+
+	return k.path[strings.LastIndex(k.path, "\\")+1:]
+}
+
+func (k *indexedKey) value(name string) *entities.Registry {
+
+	for _, v := range k.values {
+		if strings.EqualFold(v.Name, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+func (k *indexedKey) valueString(name string) string {
+
+	if v := k.value(name); v != nil {
+		return v.Value
+	}
+	return ""
+}
+
+func (k *indexedKey) child(name string) *indexedKey {
+
+	for _, c := range k.children {
+		if strings.EqualFold(c.name(), name) {
+			return c
+		}
+	}
+	return nil
+}
+
+func (k *indexedKey) lastWrite() time.Time {
+
+	if k.key != nil {
+		return k.key.LastWrite
+	}
+	if len(k.values) > 0 {
+		return k.values[0].LastWrite
+	}
+	return time.Time{}
+}
@@ -0,0 +1,70 @@
+package usecases
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/decoders"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_MRU_URL_PREFIX string = "url"
+)
+
+// mruOrder lists the item value names of an MRU key, most recent first:
+// MRUListEx holds item numbers, MRUList holds item letters, and keys with
+// neither fall back to numeric order.
+func mruOrder(k *indexedKey) []string {
+
+	names := make([]string, 0)
+	listed := make(map[string]bool)
+
+	if v := k.value("MRUListEx"); v != nil && v.Type == utils.STR_REG_BINARY {
+		data, _ := decoders.RawData(v)
+		for _, idx := range decoders.ParseMRUListEx(data) {
+			name := strconv.FormatUint(uint64(idx), 10)
+			if k.value(name) != nil && !listed[name] {
+				names = append(names, name)
+				listed[name] = true
+			}
+		}
+	} else if v := k.value("MRUList"); v != nil {
+		for _, c := range v.Value {
+			name := string(c)
+			if k.value(name) != nil && !listed[name] {
+				names = append(names, name)
+				listed[name] = true
+			}
+		}
+	}
+
+	rest := make([]string, 0)
+	for _, v := range k.values {
+		if _, ok := mruSlotNumber(v.Name); ok && !listed[v.Name] {
+			rest = append(rest, v.Name)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool {
+		a, _ := mruSlotNumber(rest[i])
+		b, _ := mruSlotNumber(rest[j])
+		return a < b
+	})
+
+	return append(names, rest...)
+}
+
+// mruSlotNumber parses numbered slots, including the "url1", "url2", ...
+// names TypedPaths uses with url1 the most recent.
+func mruSlotNumber(name string) (int, bool) {
+
+	if len(name) > len(STR_MRU_URL_PREFIX) && strings.EqualFold(name[:len(STR_MRU_URL_PREFIX)], STR_MRU_URL_PREFIX) {
+		name = name[len(STR_MRU_URL_PREFIX):]
+	}
+	if !decoders.IsMRUSlot(name) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(name)
+	return n, err == nil
+}
@@ -0,0 +1,73 @@
+package usecases
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/0736b/registry-finder-gui/decoders"
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_MRU string = "MRU Lists"
+)
+
+var mruReportColumns = []string{"User", "List", "Position", "Item", "Key Last Write", "Registry Key", "Value"}
+
+// MRUReport lists every decoded MRU in most-recent-first order. Only the
+// first item can be dated: the key was last written when it was used.
+func (u *RegistryUsecaseImpl) MRUReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_MRU, Columns: mruReportColumns}
+
+	isMRU := make(map[string]bool)
+	for _, name := range decoders.MRUDecoders {
+		isMRU[name] = true
+	}
+
+	lists := make(map[string]string)
+	for _, reg := range regs {
+		if reg.Decoded != nil && isMRU[reg.Decoded.Decoder] {
+			lists[reg.Path] = reg.Decoded.Decoder
+		}
+	}
+
+	paths := make([]string, 0, len(lists))
+	for path := range lists {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	idx := newKeyIndex(regs, func(path string) bool {
+		_, ok := lists[path]
+		return ok
+	})
+
+	deduper := newUserPathDeduper(paths)
+	for _, path := range paths {
+
+		user, ok := deduper.keep(path)
+		if !ok {
+			continue
+		}
+
+		k := idx.get(path)
+		for pos, name := range mruOrder(k) {
+
+			v := k.value(name)
+			if v.Decoded == nil {
+				continue
+			}
+
+			lastWrite := ""
+			if pos == 0 {
+				lastWrite = utils.FormatTime(k.lastWrite())
+			}
+
+			report.Rows = append(report.Rows, []string{user, lists[path], strconv.Itoa(pos), v.Decoded.Summary, lastWrite, path, name})
+		}
+	}
+
+	return report
+}
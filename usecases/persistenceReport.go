@@ -0,0 +1,193 @@
+package usecases
+
+import (
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/decoders"
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_PERSISTENCE string = "Persistence"
+	STR_DEFAULT_VALUE_NAME string = "(Default)"
+)
+
+var (
+	persistenceReportColumns = []string{"Category", "Location", "User", "Entry", "Command", "Binary", "Key Last Write", "Registry Key"}
+
+	controlSetPattern = regexp.MustCompile(`(?i)^HKEY_LOCAL_MACHINE\\SYSTEM\\(CurrentControlSet|ControlSet\d{3})\\`)
+)
+
+type asepMatcher struct {
+	location *entities.AsepLocation
+	matcher  *decoders.Matcher
+	order    int
+}
+
+// UseAsepCatalogue replaces the bundled ASEP catalogue with the JSON file at
+// path. The file is read when the first persistence report is built.
+func (u *RegistryUsecaseImpl) UseAsepCatalogue(path string) {
+
+	u.asepPath = path
+	u.aseps = nil
+}
+
+func (u *RegistryUsecaseImpl) loadAseps() []*asepMatcher {
+
+	if u.aseps != nil {
+		return u.aseps
+	}
+
+	locations, err := u.asepRepository.LoadCatalogue(u.asepPath)
+	if err != nil {
+		log.Println("loadAseps LoadCatalogue failed", err.Error())
+		return nil
+	}
+
+	u.aseps = make([]*asepMatcher, 0, len(locations))
+	for i, location := range locations {
+		u.aseps = append(u.aseps, &asepMatcher{
+			location: location,
+			matcher:  &decoders.Matcher{Path: location.Path, Names: location.Values},
+			order:    i,
+		})
+	}
+
+	return u.aseps
+}
+
+// PersistenceReport lists every value found at a catalogued autostart location
+// with the command it runs and the binary that command loads. Keys reached
+// through several views of one hive or control set are listed once.
+func (u *RegistryUsecaseImpl) PersistenceReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_PERSISTENCE, Columns: persistenceReportColumns}
+	aseps := u.loadAseps()
+
+	paths := make([]string, 0, len(regs))
+	for _, reg := range regs {
+		paths = append(paths, reg.Path)
+	}
+	deduper := newUserPathDeduper(paths)
+
+	type keptKey struct {
+		user string
+		ok   bool
+	}
+	kept := make(map[string]keptKey)
+	seen := make(map[string]bool)
+	orders := make([]int, 0)
+
+	for _, reg := range regs {
+
+		if reg.Type == "" {
+			continue
+		}
+
+		var asep *asepMatcher
+		for _, a := range aseps {
+			if a.matcher.Matches(reg) {
+				asep = a
+				break
+			}
+		}
+		if asep == nil {
+			continue
+		}
+
+		lower := strings.ToLower(reg.Path)
+		k, ok := kept[lower]
+		if !ok {
+			user, keep := deduper.keep(reg.Path)
+			k = keptKey{user: user, ok: keep}
+			kept[lower] = k
+		}
+		if !k.ok {
+			continue
+		}
+
+		for _, command := range asepCommands(reg) {
+
+			id := strings.ToLower(controlSetPattern.ReplaceAllString(reg.Path, "") + "|" + k.user + "|" + reg.Name + "|" + command)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			entry := reg.Name
+			if entry == "" {
+				entry = STR_DEFAULT_VALUE_NAME
+			}
+
+			report.Rows = append(report.Rows, []string{
+				asep.location.Category, asep.location.Name, u.sids.display(k.user, utils.STR_SID_DISPLAY_ANNOTATE),
+				entry, command, utils.CommandBinary(command), utils.FormatTime(reg.LastWrite), reg.Path,
+			})
+			orders = append(orders, asep.order)
+		}
+	}
+
+	sort.Sort(&persistenceRows{rows: report.Rows, orders: orders})
+
+	return report
+}
+
+// asepCommands returns the command lines a value holds: one per string of a
+// multi-string, and the decoded summary of binary values.
+func asepCommands(reg *entities.Registry) []string {
+
+	value := reg.Value
+	if reg.Type == utils.STR_REG_BINARY || reg.Type == utils.STR_NONE {
+		if reg.Decoded == nil {
+			return nil
+		}
+		value = reg.Decoded.Summary
+	}
+
+	commands := make([]string, 0)
+	if reg.Type == utils.STR_REG_MULTI_SZ && reg.Strings != nil {
+		for _, s := range reg.Strings {
+			if s = strings.TrimSpace(s); s != "" {
+				commands = append(commands, s)
+			}
+		}
+		return commands
+	}
+
+	if value = strings.TrimSpace(value); value != "" {
+		commands = append(commands, value)
+	}
+	return commands
+}
+
+// persistenceRows keeps the catalogue order, then sorts by key and entry.
+type persistenceRows struct {
+	rows   [][]string
+	orders []int
+}
+
+func (p *persistenceRows) Len() int {
+
+	return len(p.rows)
+}
+
+func (p *persistenceRows) Less(i, j int) bool {
+
+	if p.orders[i] != p.orders[j] {
+		return p.orders[i] < p.orders[j]
+	}
+	if p.rows[i][7] != p.rows[j][7] {
+		return p.rows[i][7] < p.rows[j][7]
+	}
+	return p.rows[i][3] < p.rows[j][3]
+}
+
+func (p *persistenceRows) Swap(i, j int) {
+
+	p.rows[i], p.rows[j] = p.rows[j], p.rows[i]
+	p.orders[i], p.orders[j] = p.orders[j], p.orders[i]
+}
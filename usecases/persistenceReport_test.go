@@ -0,0 +1,55 @@
+package usecases
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/repositories"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+func TestAsepCommands(t *testing.T) {
+
+	control := "rundll32.exe shell32.dll, Control_RunDLL"
+
+	tests := []struct {
+		name string
+		reg  *entities.Registry
+		want []string
+	}{
+		{"string", testValue(STR_MACHINE_CLASSES, "Run", " C:\\a.exe /s "), []string{"C:\\a.exe /s"}},
+		{"multi-string with a comma", &entities.Registry{Type: utils.STR_REG_MULTI_SZ, Value: control + ", C:\\b.exe", Strings: []string{control, " ", "C:\\b.exe"}}, []string{control, "C:\\b.exe"}},
+		{"binary without decoder", &entities.Registry{Type: utils.STR_REG_BINARY, Value: "00"}, nil},
+		{"decoded binary", &entities.Registry{Type: utils.STR_REG_BINARY, Value: "00", Decoded: &entities.DecodedValue{Summary: "C:\\c.exe"}}, []string{"C:\\c.exe"}},
+	}
+
+	for _, tt := range tests {
+		if got := asepCommands(tt.reg); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPersistenceReportScriptletURL(t *testing.T) {
+
+	u := &RegistryUsecaseImpl{asepRepository: repositories.NewAsepRepository(), sids: newSidResolver(nil), guids: newGuidResolver()}
+	clsid := "\\CLSID\\{00000000-0000-0000-0000-000000000001}\\ScriptletURL"
+	url := "https://example.com/x.sct"
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"machine classes", STR_MACHINE_CLASSES + clsid},
+		{"user classes in the user hive", utils.STR_HKEY_USERS + "\\" + STR_TEST_SID + "\\Software\\Classes" + clsid},
+		{"user classes hive", utils.STR_HKEY_USERS + "\\" + STR_TEST_SID + STR_USER_CLASSES_SUFFIX + clsid},
+	}
+
+	for _, tt := range tests {
+		report := u.PersistenceReport([]*entities.Registry{testValue(tt.path, "", url)})
+		if len(report.Rows) != 1 || report.Rows[0][1] != "COM ScriptletURL" || report.Rows[0][4] != url {
+			t.Errorf("%s: rows = %v, want one COM ScriptletURL row", tt.name, report.Rows)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package usecases
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_POLICY_ENABLED  string = "Enabled"
+	STR_POLICY_DISABLED string = "Disabled"
+	STR_POLICY_UNKNOWN  string = "Unknown value"
+)
+
+type policyRef struct {
+	policy   *entities.Policy
+	element  *entities.PolicyElement
+	item     *entities.PolicyListItem
+	disabled bool
+}
+
+type policyIndex struct {
+	values map[string][]*policyRef
+	lists  map[string][]*policyRef
+}
+
+func newPolicyIndex(policies []*entities.Policy) *policyIndex {
+
+	idx := &policyIndex{values: make(map[string][]*policyRef), lists: make(map[string][]*policyRef)}
+
+	for _, p := range policies {
+
+		classes := []string{p.Class}
+		if p.Class == utils.STR_POLICY_CLASS_BOTH {
+			classes = []string{utils.STR_POLICY_CLASS_MACHINE, utils.STR_POLICY_CLASS_USER}
+		}
+
+		for _, class := range classes {
+
+			if p.ValueName != "" {
+				idx.addValue(class, p.Key, p.ValueName, &policyRef{policy: p})
+			}
+
+			for _, item := range p.EnabledList {
+				idx.addValue(class, item.Key, item.ValueName, &policyRef{policy: p, item: item})
+			}
+			for _, item := range p.DisabledList {
+				idx.addValue(class, item.Key, item.ValueName, &policyRef{policy: p, item: item, disabled: true})
+			}
+
+			for _, elem := range p.Elements {
+				if elem.Kind == "list" {
+					k := policyIndexKey(class, elem.Key)
+					idx.lists[k] = append(idx.lists[k], &policyRef{policy: p, element: elem})
+				} else if elem.ValueName != "" {
+					idx.addValue(class, elem.Key, elem.ValueName, &policyRef{policy: p, element: elem})
+				}
+			}
+		}
+	}
+
+	return idx
+}
+
+func (idx *policyIndex) addValue(class string, key string, valueName string, ref *policyRef) {
+
+	k := policyIndexKey(class, key+"\\"+valueName)
+	idx.values[k] = append(idx.values[k], ref)
+}
+
+func (idx *policyIndex) lookup(reg *entities.Registry) []*policyRef {
+
+	class, rest := splitPolicyPath(reg.Path)
+	if class == "" {
+		return nil
+	}
+
+	if refs, ok := idx.values[policyIndexKey(class, rest+"\\"+reg.Name)]; ok {
+		return refs
+	}
+
+	return idx.lists[policyIndexKey(class, rest)]
+}
+
+func policyIndexKey(class string, path string) string {
+
+	return class + "|" + strings.ToLower(path)
+}
+
+// splitPolicyPath maps a registry path onto the ADMX class that writes to it
+// and the hive-relative key used in ADMX definitions.
+func splitPolicyPath(path string) (string, string) {
+
+	switch {
+	case strings.HasPrefix(path, utils.STR_HKEY_LOCAL_MACHINE+"\\"):
+		return utils.STR_POLICY_CLASS_MACHINE, path[len(utils.STR_HKEY_LOCAL_MACHINE)+1:]
+	case strings.HasPrefix(path, utils.STR_HKEY_CURRENT_USER+"\\"):
+		return utils.STR_POLICY_CLASS_USER, path[len(utils.STR_HKEY_CURRENT_USER)+1:]
+	case strings.HasPrefix(path, utils.STR_HKEY_USERS+"\\"):
+		rest := path[len(utils.STR_HKEY_USERS)+1:]
+		if i := strings.Index(rest, "\\"); i >= 0 {
+			return utils.STR_POLICY_CLASS_USER, rest[i+1:]
+		}
+	}
+
+	return "", ""
+}
+
+func (u *RegistryUsecaseImpl) UsePolicyDefinitions(dir string) {
+
+	u.policyDir = dir
+}
+
+func (u *RegistryUsecaseImpl) loadPolicies() {
+
+	if u.policyDir == "" {
+		return
+	}
+
+	policies, err := u.policyRepository.LoadPolicyDefinitions(u.policyDir)
+	if err != nil {
+		log.Println("loadPolicies LoadPolicyDefinitions failed", err.Error())
+		return
+	}
+
+	u.policies = newPolicyIndex(policies)
+}
+
+func (u *RegistryUsecaseImpl) annotatePolicy(reg *entities.Registry) {
+
+	if u.policies == nil || reg.Type == "" {
+		return
+	}
+
+	refs := u.policies.lookup(reg)
+	if len(refs) == 0 {
+		return
+	}
+
+	ref := refs[0]
+	meaning := policyMeaning(reg, ref)
+	for _, r := range refs[1:] {
+		if meaning != STR_POLICY_UNKNOWN {
+			break
+		}
+		ref, meaning = r, policyMeaning(reg, r)
+	}
+
+	reg.Policy = &entities.PolicyAnnotation{
+		DisplayName: ref.policy.DisplayName,
+		Category:    ref.policy.Category,
+		ExplainText: ref.policy.ExplainText,
+		Meaning:     meaning,
+	}
+}
+
+func policyMeaning(reg *entities.Registry, ref *policyRef) string {
+
+	switch {
+
+	case ref.item != nil:
+		if !policyValueMatches(reg, ref.item.Value) {
+			return STR_POLICY_UNKNOWN
+		}
+		if ref.disabled {
+			return STR_POLICY_DISABLED
+		}
+		return STR_POLICY_ENABLED
+
+	case ref.element != nil:
+		return policyElementMeaning(reg, ref.element)
+
+	default:
+		enabled, disabled := ref.policy.EnabledValue, ref.policy.DisabledValue
+		if enabled == nil && disabled == nil {
+			enabled = &entities.PolicyValue{Kind: utils.STR_POLICY_VALUE_DECIMAL, Data: "1"}
+			disabled = &entities.PolicyValue{Kind: utils.STR_POLICY_VALUE_DECIMAL, Data: "0"}
+		}
+		if policyValueMatches(reg, enabled) {
+			return STR_POLICY_ENABLED
+		}
+		if policyValueMatches(reg, disabled) {
+			return STR_POLICY_DISABLED
+		}
+		return STR_POLICY_UNKNOWN
+	}
+}
+
+func policyElementMeaning(reg *entities.Registry, elem *entities.PolicyElement) string {
+
+	switch elem.Kind {
+
+	case "enum":
+		for _, item := range elem.Items {
+			if policyValueMatches(reg, item.Value) {
+				return item.DisplayName
+			}
+		}
+		return STR_POLICY_UNKNOWN
+
+	case "boolean":
+		trueValue, falseValue := elem.TrueValue, elem.FalseValue
+		if trueValue == nil && falseValue == nil {
+			trueValue = &entities.PolicyValue{Kind: utils.STR_POLICY_VALUE_DECIMAL, Data: "1"}
+			falseValue = &entities.PolicyValue{Kind: utils.STR_POLICY_VALUE_DECIMAL, Data: "0"}
+		}
+		if policyValueMatches(reg, trueValue) {
+			return elem.ID + ": True"
+		}
+		if policyValueMatches(reg, falseValue) {
+			return elem.ID + ": False"
+		}
+		return STR_POLICY_UNKNOWN
+
+	default:
+		return elem.ID + " = " + reg.Value
+	}
+}
+
+func policyValueMatches(reg *entities.Registry, val *entities.PolicyValue) bool {
+
+	if val == nil {
+		return false
+	}
+
+	switch val.Kind {
+	case utils.STR_POLICY_VALUE_DECIMAL:
+		want, err := strconv.ParseUint(val.Data, 10, 64)
+		if err != nil {
+			return false
+		}
+		got, err := strconv.ParseUint(reg.Value, 0, 64)
+		return err == nil && got == want
+	case utils.STR_POLICY_VALUE_STRING:
+		return reg.Value == val.Data
+	default:
+		return false
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/0736b/registry-finder-gui/decoders"
 	"github.com/0736b/registry-finder-gui/entities"
 	"github.com/0736b/registry-finder-gui/repositories"
 	"github.com/0736b/registry-finder-gui/utils"
@@ -14,21 +15,74 @@ type RegistryUsecase interface {
 	FilterByKeyword(reg *entities.Registry, keyword string) bool
 	FilterByKey(reg *entities.Registry, filterKey string) bool
 	FilterByType(reg *entities.Registry, filterType string) bool
+	PathUnder(reg *entities.Registry, filterKey string) string
 	OpenInRegedit(reg *entities.Registry)
+	UsePolicyDefinitions(dir string)
+	ExportRegistry(path string, regs []*entities.Registry) error
+	Timeline(regs []*entities.Registry) []*entities.TimelineEvent
+	ExportTimeline(path string, events []*entities.TimelineEvent) error
+	FilterReportRow(report *entities.Report, row []string, query string) bool
+	ReportEntities(regs []*entities.Registry, includeRegBack bool) []*entities.Registry
+	ExportReport(path string, report *entities.Report) error
+	ShellBagsReport(regs []*entities.Registry) *entities.Report
+	ShimCacheReport(regs []*entities.Registry) *entities.Report
+	AmcacheReport(regs []*entities.Registry) *entities.Report
+	MRUReport(regs []*entities.Registry) *entities.Report
+	DisplaySIDs(s string, mode string) string
+	AnnotateGUIDs(s string) string
+	BAMReport(regs []*entities.Registry) *entities.Report
+	SAMReport(regs []*entities.Registry) *entities.Report
+	UseAsepCatalogue(path string)
+	PersistenceReport(regs []*entities.Registry) *entities.Report
+	ServicesReport(regs []*entities.Registry) *entities.Report
+	UninstallReport(regs []*entities.Registry) *entities.Report
+	USBReport(regs []*entities.Registry) *entities.Report
+	ResolveAssociation(regs []*entities.Registry, query string) *entities.Report
+	AssociationReport(regs []*entities.Registry) *entities.Report
+	InspectCOMObject(regs []*entities.Registry, query string) *entities.Report
+	COMHijackReport(regs []*entities.Registry) *entities.Report
+	FirewallReport(regs []*entities.Registry) *entities.Report
+	TaskCacheReport(regs []*entities.Registry) *entities.Report
+	EnvironmentReport(regs []*entities.Registry) *entities.Report
+	UseEnvironmentFile(path string)
+	ExpandValue(reg *entities.Registry) string
 }
 
 type RegistryUsecaseImpl struct {
-	registryRepository repositories.RegistryRepository
+	registryRepository    repositories.RegistryRepository
+	policyRepository      repositories.PolicyRepository
+	asepRepository        repositories.AsepRepository
+	environmentRepository repositories.EnvironmentRepository
+
+	policyDir string
+	policies  *policyIndex
+
+	asepPath string
+	aseps    []*asepMatcher
+
+	// imageRoot is the mounted volume offline hives were read from, empty when
+	// reading the live registry.
+	imageRoot string
+
+	sids      *sidResolver
+	guids     *guidResolver
+	expansion *expansion
 }
 
 var (
-	singletonRegistryRepository repositories.RegistryRepository = nil
+	singletonRegistryRepository    repositories.RegistryRepository    = nil
+	singletonPolicyRepository      repositories.PolicyRepository      = nil
+	singletonAsepRepository        repositories.AsepRepository        = nil
+	singletonEnvironmentRepository repositories.EnvironmentRepository = nil
 
 	keywordCache   = make(map[string]string)
 	keywordCacheMu sync.RWMutex
 
 	toLowerCache   = make(map[string]string)
 	toLowerCacheMu sync.RWMutex
+
+	filterKeyCache   = make(map[string]string)
+	filterKeyCacheMu sync.RWMutex
 )
 
 func NewRegistryUsecase() *RegistryUsecaseImpl {
@@ -36,12 +90,62 @@ func NewRegistryUsecase() *RegistryUsecaseImpl {
 	if singletonRegistryRepository == nil {
 		singletonRegistryRepository = repositories.NewRegistryRepository()
 	}
-	return &RegistryUsecaseImpl{registryRepository: singletonRegistryRepository}
+	if singletonPolicyRepository == nil {
+		singletonPolicyRepository = repositories.NewPolicyRepository()
+	}
+	if singletonAsepRepository == nil {
+		singletonAsepRepository = repositories.NewAsepRepository()
+	}
+	if singletonEnvironmentRepository == nil {
+		singletonEnvironmentRepository = repositories.NewEnvironmentRepository()
+	}
+	return &RegistryUsecaseImpl{registryRepository: singletonRegistryRepository, policyRepository: singletonPolicyRepository, asepRepository: singletonAsepRepository, environmentRepository: singletonEnvironmentRepository, sids: newSidResolver(nil), guids: newGuidResolver(), expansion: newExpansion()}
+}
+
+// NewImageRegistryUsecase reads every hive found under the root of a mounted
+// Windows volume instead of the live registry. user selects whose classes are
+// merged into HKEY_CLASSES_ROOT, empty picks the most recently used profile.
+func NewImageRegistryUsecase(root string, user string) *RegistryUsecaseImpl {
+
+	u := NewRegistryUsecase()
+	u.registryRepository = repositories.NewImageRegistryRepository(root, user)
+	u.imageRoot = root
+	return u
+}
+
+// NewDedupedRegistryUsecase scans each physical live key once and reports
+// HKCU, HKCR, HKCC and CurrentControlSet paths as aliases instead.
+func NewDedupedRegistryUsecase() *RegistryUsecaseImpl {
+
+	u := NewRegistryUsecase()
+	u.registryRepository = repositories.NewDedupedRegistryRepository()
+	return u
 }
 
 func (u *RegistryUsecaseImpl) StreamRegistry() <-chan *entities.Registry {
 
-	return u.registryRepository.StreamRegistry()
+	stream := u.registryRepository.StreamRegistry()
+	annotatedChan := make(chan *entities.Registry)
+
+	go func() {
+		defer close(annotatedChan)
+
+		u.loadPolicies()
+
+		for reg := range stream {
+			if reg == nil {
+				continue
+			}
+			u.annotatePolicy(reg)
+			reg.Decoded = decoders.Decode(reg)
+			u.sids.learn(reg)
+			u.guids.learn(reg)
+			u.expansion.learn(reg)
+			annotatedChan <- reg
+		}
+	}()
+
+	return annotatedChan
 }
 
 func (u *RegistryUsecaseImpl) FilterByKeyword(reg *entities.Registry, keyword string) bool {
@@ -68,18 +172,87 @@ func (u *RegistryUsecaseImpl) FilterByKeyword(reg *entities.Registry, keyword st
 	}
 
 	if !regExists {
-		processedReg = utils.PreProcessStr(regStr + reg.Value)
+		processedReg = utils.PreProcessStr(regStr + reg.Value + policySearchText(reg) + decodedSearchText(reg) + strings.Join(reg.Aliases, "") + reg.Origin)
 		toLowerCacheMu.Lock()
 		toLowerCache[regStr] = processedReg
 		toLowerCacheMu.Unlock()
 	}
 
-	return strings.Contains(processedReg, processedKeyword)
+	if strings.Contains(processedReg, processedKeyword) {
+		return true
+	}
+
+	// GUID names are learned while streaming, so their cache is per generation
+	if strings.Contains(u.guids.processedSearchText(reg), processedKeyword) {
+		return true
+	}
+
+	// so is an offline environment, REG_EXPAND_SZ values also match expanded
+	return strings.Contains(utils.PreProcessStr(u.ExpandValue(reg)), processedKeyword)
 }
 
+// FilterByKey accepts both canonical and alias forms of the key path, as well as
+// abbreviated roots such as HKLM\SYSTEM\CurrentControlSet and HKU\<username>.
 func (u *RegistryUsecaseImpl) FilterByKey(reg *entities.Registry, filterKey string) bool {
 
-	return strings.HasPrefix(reg.Path, filterKey)
+	filterKeyCacheMu.RLock()
+	normalizedKey, keyExists := filterKeyCache[filterKey]
+	filterKeyCacheMu.RUnlock()
+
+	if !keyExists {
+		normalizedKey = utils.NormalizeKeyPath(filterKey)
+		filterKeyCacheMu.Lock()
+		filterKeyCache[filterKey] = normalizedKey
+		filterKeyCacheMu.Unlock()
+	}
+
+	// user names are learned while streaming, so they are not cached
+	normalizedKey = u.sids.expandUserPath(normalizedKey)
+
+	if utils.HasPrefixFold(reg.Path, normalizedKey) {
+		return true
+	}
+
+	for _, alias := range reg.Aliases {
+		if utils.HasPrefixFold(alias, normalizedKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PathUnder presents the entity under the alias matching filterKey, so a
+// deduplicated HKU\<SID> entity reads as HKCU when filtering by HKCU.
+func (u *RegistryUsecaseImpl) PathUnder(reg *entities.Registry, filterKey string) string {
+
+	filterKey = u.sids.expandUserPath(utils.NormalizeKeyPath(filterKey))
+
+	if utils.HasPrefixFold(reg.Path, filterKey) {
+		return reg.Path
+	}
+
+	for _, alias := range reg.Aliases {
+		if utils.HasPrefixFold(alias, filterKey) {
+			return alias
+		}
+	}
+
+	return reg.Path
+}
+
+// DisplaySIDs annotates or substitutes the SIDs in s with the account names
+// learned so far, mode is one of the utils.STR_SID_DISPLAY_* options.
+func (u *RegistryUsecaseImpl) DisplaySIDs(s string, mode string) string {
+
+	return u.sids.display(s, mode)
+}
+
+// AnnotateGUIDs appends the CLSID, Interface, TypeLib, AppID or known folder
+// name after every GUID in s.
+func (u *RegistryUsecaseImpl) AnnotateGUIDs(s string) string {
+
+	return u.guids.annotate(s)
 }
 
 func (u *RegistryUsecaseImpl) FilterByType(reg *entities.Registry, filterType string) bool {
@@ -91,3 +264,19 @@ func (u *RegistryUsecaseImpl) OpenInRegedit(reg *entities.Registry) {
 
 	utils.OpenRegeditAtPath(reg.Path)
 }
+
+func policySearchText(reg *entities.Registry) string {
+
+	if reg.Policy == nil {
+		return ""
+	}
+	return reg.Policy.DisplayName + reg.Policy.Category + reg.Policy.Meaning
+}
+
+func decodedSearchText(reg *entities.Registry) string {
+
+	if reg.Decoded == nil {
+		return ""
+	}
+	return reg.Decoded.Summary
+}
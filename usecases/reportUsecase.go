@@ -0,0 +1,99 @@
+package usecases
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/repositories"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+// FilterReportRow matches every whitespace separated term of query against the
+// row. A "column:text" term only looks at the named column, and a numeric term
+// matches the range columns of the report, such as firewall port lists, by
+// value rather than as text.
+func (u *RegistryUsecaseImpl) FilterReportRow(report *entities.Report, row []string, query string) bool {
+
+	for _, term := range strings.Fields(query) {
+
+		col := -1
+		if i := strings.Index(term, ":"); i > 0 {
+			for c, title := range report.Columns {
+				if utils.PreProcessStr(title) == strings.ToLower(term[:i]) {
+					col, term = c, term[i+1:]
+					break
+				}
+			}
+		}
+
+		matched := false
+		for c, cell := range row {
+			if col >= 0 && c != col {
+				continue
+			}
+			if cellMatches(slices.Contains(report.RangeColumns, report.Columns[c]), cell, term) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ReportEntities drops the RegBack copies an offline image mounts under
+// HKLM\RegBack unless includeRegBack is set. Their keys match the same
+// patterns as the current hives and would add rows that look current.
+func (u *RegistryUsecaseImpl) ReportEntities(regs []*entities.Registry, includeRegBack bool) []*entities.Registry {
+
+	if includeRegBack {
+		return regs
+	}
+
+	current := make([]*entities.Registry, 0, len(regs))
+	for _, reg := range regs {
+		if !utils.HasPrefixFold(reg.Path+"\\", repositories.IMAGE_MOUNT_REGBACK+"\\") {
+			current = append(current, reg)
+		}
+	}
+	return current
+}
+
+func (u *RegistryUsecaseImpl) ExportReport(path string, report *entities.Report) error {
+
+	return writeCSV(path, report.Columns, report.Rows)
+}
+
+func cellMatches(ranges bool, cell string, term string) bool {
+
+	if ranges {
+		if n, err := strconv.Atoi(term); err == nil {
+			return portListContains(cell, n)
+		}
+	}
+
+	return strings.Contains(utils.PreProcessStr(cell), utils.PreProcessStr(term))
+}
+
+func portListContains(cell string, n int) bool {
+
+	for _, part := range strings.Split(cell, ",") {
+		lo, hi, found := strings.Cut(strings.TrimSpace(part), "-")
+		if !found {
+			hi = lo
+		}
+		l, errLo := strconv.Atoi(lo)
+		h, errHi := strconv.Atoi(hi)
+		if errLo == nil && errHi == nil && l <= n && n <= h {
+			return true
+		}
+	}
+
+	return false
+}
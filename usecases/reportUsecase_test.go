@@ -0,0 +1,67 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/repositories"
+)
+
+func TestFilterReportRow(t *testing.T) {
+
+	u := &RegistryUsecaseImpl{}
+
+	firewall := &entities.Report{Columns: []string{"Name", STR_FIREWALL_LOCAL_PORTS, STR_FIREWALL_REMOTE_PORTS, "Rule ID"}, RangeColumns: firewallPortColumns}
+	firewallRow := []string{"Remote Desktop", "80,443,1000-2000", "RPC", "1001"}
+	untagged := &entities.Report{Columns: firewall.Columns}
+
+	uninstall := &entities.Report{Columns: []string{"Name", "Install Date", "Size"}}
+	uninstallRow := []string{"Tool", "2023-01-05", "1001"}
+
+	tests := []struct {
+		name   string
+		report *entities.Report
+		row    []string
+		query  string
+		want   bool
+	}{
+		{"port in a range", firewall, firewallRow, "localports:1500", true},
+		{"port in a list", firewall, firewallRow, "443", true},
+		{"port outside the ranges", firewall, firewallRow, "localports:3389", false},
+		{"port column matches numbers by value only", firewall, firewallRow, "localports:44", false},
+		{"port column matches words as text", firewall, firewallRow, "remoteports:rpc", true},
+		{"ranges only where the report declares them", untagged, firewallRow, "localports:1500", false},
+		{"number in another column is a substring", firewall, firewallRow, "ruleid:100", true},
+		{"range semantics only for port columns", firewall, firewallRow, "ruleid:1500", false},
+		{"date prefix", uninstall, uninstallRow, "installdate:2023", true},
+		{"date part", uninstall, uninstallRow, "01-05", true},
+		{"partial number", uninstall, uninstallRow, "100", true},
+		{"every term must match", uninstall, uninstallRow, "tool 2024", false},
+		{"unknown column searches the whole row", uninstall, uninstallRow, "vendor:tool", false},
+		{"empty query", uninstall, uninstallRow, "", true},
+	}
+
+	for _, tt := range tests {
+		if got := u.FilterReportRow(tt.report, tt.row, tt.query); got != tt.want {
+			t.Errorf("%s: FilterReportRow(%q) = %v, want %v", tt.name, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestReportEntities(t *testing.T) {
+
+	u := &RegistryUsecaseImpl{}
+
+	software := testValue(repositories.IMAGE_MOUNT_SOFTWARE+"\\Microsoft\\Windows\\CurrentVersion\\Run", "Tool", "C:\\tool.exe")
+	regBack := testValue(repositories.IMAGE_MOUNT_REGBACK+"\\SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\Run", "Tool", "C:\\tool.exe")
+	regBackRoot := &entities.Registry{Path: repositories.IMAGE_MOUNT_REGBACK}
+	lookalike := &entities.Registry{Path: repositories.IMAGE_MOUNT_REGBACK + "up"}
+	regs := []*entities.Registry{software, regBack, regBackRoot, lookalike}
+
+	if got := u.ReportEntities(regs, false); len(got) != 2 || got[0] != software || got[1] != lookalike {
+		t.Errorf("without RegBack = %v, want the SOFTWARE value and %s", got, lookalike.Path)
+	}
+	if got := u.ReportEntities(regs, true); len(got) != len(regs) {
+		t.Errorf("with RegBack = %d entities, want %d", len(got), len(regs))
+	}
+}
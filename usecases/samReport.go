@@ -0,0 +1,118 @@
+package usecases
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/decoders"
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_SAM string = "Local Accounts"
+
+	STR_SAM_DOMAINS       string = "\\Domains\\"
+	STR_SAM_ACCOUNT_USERS string = "\\Domains\\Account\\Users"
+)
+
+var samReportColumns = []string{"RID", "Username", "Full Name", "Comment", "Flags", "Last Logon", "Password Last Set", "Last Failed Logon", "Logon Count", "Failed Logon Count", "Groups", "SID", "Registry Key"}
+
+// SAMReport lists the local accounts of every SAM hive with their group
+// memberships. Password hashes stay undecoded.
+func (u *RegistryUsecaseImpl) SAMReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_SAM, Columns: samReportColumns}
+
+	idx := newKeyIndex(regs, func(path string) bool {
+		return strings.Contains(strings.ToLower(path), strings.ToLower(STR_SAM_DOMAINS))
+	})
+
+	roots := make([]string, 0)
+	for _, k := range idx.keys {
+		if strings.HasSuffix(strings.ToLower(k.path), strings.ToLower(STR_SAM_ACCOUNT_USERS)) {
+			roots = append(roots, k.path[:len(k.path)-len(STR_SAM_ACCOUNT_USERS)])
+		}
+	}
+	sort.Strings(roots)
+
+	for _, root := range roots {
+		appendSAMRows(idx, root, report)
+	}
+
+	return report
+}
+
+func appendSAMRows(idx *keyIndex, root string, report *entities.Report) {
+
+	domainSid := ""
+	if account := idx.get(root + "\\Domains\\Account"); account != nil {
+		if data, ok := rawValue(account, "V"); ok {
+			domainSid, _ = decoders.ParseSAMDomainSID(data)
+		}
+	}
+
+	groups := make(map[string][]string)
+	for _, domain := range []string{"Builtin", "Account"} {
+		aliases := idx.get(root + "\\Domains\\" + domain + "\\Aliases")
+		if aliases == nil {
+			continue
+		}
+		for _, k := range aliases.children {
+			data, ok := rawValue(k, "C")
+			if !ok {
+				continue
+			}
+			alias, err := decoders.ParseSAMAlias(data)
+			if err != nil {
+				continue
+			}
+			for _, member := range alias.Members {
+				groups[strings.ToUpper(member)] = append(groups[strings.ToUpper(member)], alias.Name)
+			}
+		}
+	}
+
+	for _, k := range idx.get(root + STR_SAM_ACCOUNT_USERS).children {
+
+		fData, ok := rawValue(k, "F")
+		if !ok {
+			continue
+		}
+		f, err := decoders.ParseSAMAccountF(fData)
+		if err != nil {
+			continue
+		}
+
+		v := &decoders.SAMAccountV{}
+		if vData, ok := rawValue(k, "V"); ok {
+			if parsed, err := decoders.ParseSAMAccountV(vData); err == nil {
+				v = parsed
+			}
+		}
+
+		rid := strconv.FormatUint(uint64(f.RID), 10)
+		sid := ""
+		if domainSid != "" {
+			sid = domainSid + "-" + rid
+		}
+
+		report.Rows = append(report.Rows, []string{
+			rid, v.Username, v.FullName, v.Comment, decoders.SAMAccountFlags(f.Flags),
+			utils.FormatTime(f.LastLogon), utils.FormatTime(f.PasswordLastSet), utils.FormatTime(f.LastFailedLogon),
+			strconv.FormatUint(uint64(f.LogonCount), 10), strconv.FormatUint(uint64(f.FailedCount), 10),
+			strings.Join(groups[strings.ToUpper(sid)], ", "), sid, k.path,
+		})
+	}
+}
+
+func rawValue(k *indexedKey, name string) ([]byte, bool) {
+
+	v := k.value(name)
+	if v == nil {
+		return nil, false
+	}
+	data, err := decoders.RawData(v)
+	return data, err == nil
+}
@@ -0,0 +1,127 @@
+package usecases
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/decoders"
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_SERVICES string = "Services"
+
+	STR_WINDOWS_NT_CURRENT_VERSION string = utils.STR_HKEY_LOCAL_MACHINE + "\\SOFTWARE\\Microsoft\\Windows NT\\CurrentVersion"
+)
+
+var servicesReportColumns = []string{"Service", "Display Name", "Start", "Type", "Error Control", "Image Path", "Service DLL", "Object Name", "Dependencies", "Failure Actions", "Key Last Write", "Registry Key"}
+
+// ServicesReport turns each service key of every control set into one row
+// with its start mode, type and error control decoded and its image path
+// normalized. Services identical across control sets are listed once.
+func (u *RegistryUsecaseImpl) ServicesReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_SERVICES, Columns: servicesReportColumns}
+
+	idx := newKeyIndex(regs, func(path string) bool {
+		return utils.MatchPathPattern(decoders.STR_SERVICE_KEY_PATTERN+"\\**", path)
+	})
+
+	// the Windows directory of the scanned system, also for offline images
+	systemRoot := ""
+	for _, reg := range regs {
+		if reg.Name == "SystemRoot" && strings.EqualFold(reg.Path, STR_WINDOWS_NT_CURRENT_VERSION) {
+			systemRoot = reg.Value
+			break
+		}
+	}
+
+	services := make([]*indexedKey, 0)
+	for _, k := range idx.keys {
+		if utils.MatchPathPattern(decoders.STR_SERVICE_KEY_PATTERN, k.path) && (k.value("Type") != nil || k.value("ImagePath") != nil) {
+			services = append(services, k)
+		}
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return strings.ToLower(services[i].path) < strings.ToLower(services[j].path)
+	})
+
+	seen := make(map[string]bool)
+	for _, k := range services {
+
+		row := serviceRow(k, systemRoot)
+		id := strings.ToLower(strings.Join(row[:len(row)-2], "|"))
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		report.Rows = append(report.Rows, row)
+	}
+
+	return report
+}
+
+func serviceRow(k *indexedKey, systemRoot string) []string {
+
+	dword := func(name string) (uint32, bool) {
+		v, err := strconv.ParseUint(k.valueString(name), 0, 32)
+		return uint32(v), err == nil
+	}
+
+	start := ""
+	if v, ok := dword("Start"); ok {
+		delayed, _ := dword("DelayedAutostart")
+		start = decoders.ServiceStartName(v, delayed == 1)
+	}
+
+	serviceType := ""
+	if v, ok := dword("Type"); ok {
+		serviceType = decoders.ServiceTypeName(v)
+	}
+
+	errorControl := ""
+	if v, ok := dword("ErrorControl"); ok {
+		errorControl = decoders.ServiceErrorControlName(v)
+	}
+
+	imagePath := k.valueString("ImagePath")
+	if imagePath != "" {
+		imagePath = utils.NormalizeImagePath(imagePath, systemRoot)
+	}
+
+	serviceDll := ""
+	if params := k.child("Parameters"); params != nil && params.valueString("ServiceDll") != "" {
+		serviceDll = utils.NormalizeImagePath(params.valueString("ServiceDll"), systemRoot)
+	}
+
+	dependencies := make([]string, 0)
+	for _, dep := range strings.Split(k.valueString("DependOnService"), ", ") {
+		if dep != "" {
+			dependencies = append(dependencies, dep)
+		}
+	}
+	for _, group := range strings.Split(k.valueString("DependOnGroup"), ", ") {
+		if group != "" {
+			dependencies = append(dependencies, "+"+group)
+		}
+	}
+
+	failureActions := ""
+	if data, ok := rawValue(k, "FailureActions"); ok {
+		if fa, err := decoders.ParseServiceFailureActions(data); err == nil {
+			failureActions = fa.String()
+		}
+	}
+	if command := k.valueString("FailureCommand"); command != "" {
+		failureActions += "; command " + command
+	}
+
+	return []string{
+		k.name(), k.valueString("DisplayName"), start, serviceType, errorControl, imagePath, serviceDll,
+		k.valueString("ObjectName"), strings.Join(dependencies, ", "), failureActions,
+		utils.FormatTime(k.lastWrite()), k.path,
+	}
+}
@@ -0,0 +1,80 @@
+package usecases
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/decoders"
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_SHELLBAGS string = "ShellBags"
+)
+
+var shellBagsReportColumns = []string{"User", "Folder Path", "Item Type", "MFT Entry", "MFT Sequence", "Modified", "Accessed", "Created", "MRU Position", "Registry Key", "Slot"}
+
+// ShellBagsReport rebuilds the folder paths recorded under every BagMRU tree
+// by joining the shell items of each slot with those of its parent slots.
+func (u *RegistryUsecaseImpl) ShellBagsReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_SHELLBAGS, Columns: shellBagsReportColumns}
+
+	idx := newKeyIndex(regs, func(path string) bool {
+		return strings.Contains(strings.ToLower(path), "\\bagmru")
+	})
+
+	roots := make([]string, 0)
+	for _, k := range idx.keys {
+		if strings.EqualFold(k.name(), "BagMRU") {
+			roots = append(roots, k.path)
+		}
+	}
+	sort.Strings(roots)
+
+	deduper := newUserPathDeduper(roots)
+	for _, root := range roots {
+		if user, ok := deduper.keep(root); ok {
+			walkBagMRU(idx.get(root), user, "", report)
+		}
+	}
+
+	return report
+}
+
+func walkBagMRU(k *indexedKey, user string, parentPath string, report *entities.Report) {
+
+	for pos, slot := range mruOrder(k) {
+
+		data, err := decoders.RawData(k.value(slot))
+		if err != nil {
+			continue
+		}
+
+		items, err := decoders.ParseIDList(data)
+		if err != nil {
+			continue
+		}
+
+		item := items[len(items)-1]
+		path := decoders.JoinShellPath(parentPath, decoders.IDListPath(items))
+
+		mftEntry, mftSeq := "", ""
+		if item.MFTEntry != 0 {
+			mftEntry = strconv.FormatUint(item.MFTEntry, 10)
+			mftSeq = strconv.FormatUint(uint64(item.MFTSeq), 10)
+		}
+
+		report.Rows = append(report.Rows, []string{
+			user, path, item.Type, mftEntry, mftSeq,
+			utils.FormatTime(item.Modified), utils.FormatTime(item.Accessed), utils.FormatTime(item.Created),
+			strconv.Itoa(pos), k.path, slot,
+		})
+
+		if child := k.child(slot); child != nil {
+			walkBagMRU(child, user, path, report)
+		}
+	}
+}
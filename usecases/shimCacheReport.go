@@ -0,0 +1,47 @@
+package usecases
+
+import (
+	"strconv"
+
+	"github.com/0736b/registry-finder-gui/decoders"
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_SHIMCACHE string = "ShimCache"
+)
+
+var shimCacheReportColumns = []string{"Position", "Path", "Last Modified", "Executed", "Data Size", "Version", "Registry Key"}
+
+// ShimCacheReport lists the AppCompatCache entries of every control set in
+// cache order. Identical caches reached through CurrentControlSet are listed once.
+func (u *RegistryUsecaseImpl) ShimCacheReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_SHIMCACHE, Columns: shimCacheReportColumns}
+	seen := make(map[string]bool)
+
+	for _, reg := range regs {
+
+		if reg.Decoded == nil || reg.Decoded.Decoder != decoders.DECODER_SHIMCACHE || seen[reg.Value] {
+			continue
+		}
+		seen[reg.Value] = true
+
+		data, err := decoders.RawData(reg)
+		if err != nil {
+			continue
+		}
+
+		version, entries, _ := decoders.ParseShimCache(data)
+		for _, entry := range entries {
+			report.Rows = append(report.Rows, []string{
+				strconv.Itoa(entry.Position), entry.Path, utils.FormatTime(entry.LastModified),
+				decoders.ShimCacheExecuted(entry), strconv.FormatUint(uint64(entry.DataSize), 10),
+				version, reg.Path,
+			})
+		}
+	}
+
+	return report
+}
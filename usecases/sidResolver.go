@@ -0,0 +1,248 @@
+package usecases
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/0736b/registry-finder-gui/decoders"
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_PROFILE_LIST string = utils.STR_HKEY_LOCAL_MACHINE + "\\SOFTWARE\\Microsoft\\Windows NT\\CurrentVersion\\ProfileList\\"
+
+	STR_SAM_ACCOUNT_DOMAIN string = "\\Domains\\Account"
+)
+
+var (
+	sidPattern = regexp.MustCompile(`S-1-\d+(-\d+)+`)
+
+	wellKnownSids = map[string]string{
+		"S-1-0-0":      "NULL SID",
+		"S-1-1-0":      "Everyone",
+		"S-1-2-0":      "LOCAL",
+		"S-1-3-0":      "CREATOR OWNER",
+		"S-1-3-1":      "CREATOR GROUP",
+		"S-1-5-2":      "NETWORK",
+		"S-1-5-4":      "INTERACTIVE",
+		"S-1-5-6":      "SERVICE",
+		"S-1-5-7":      "ANONYMOUS LOGON",
+		"S-1-5-9":      "ENTERPRISE DOMAIN CONTROLLERS",
+		"S-1-5-10":     "SELF",
+		"S-1-5-11":     "Authenticated Users",
+		"S-1-5-12":     "RESTRICTED",
+		"S-1-5-18":     "SYSTEM",
+		"S-1-5-19":     "LOCAL SERVICE",
+		"S-1-5-20":     "NETWORK SERVICE",
+		"S-1-5-32-544": "Administrators",
+		"S-1-5-32-545": "Users",
+		"S-1-5-32-546": "Guests",
+		"S-1-5-32-547": "Power Users",
+		"S-1-5-32-551": "Backup Operators",
+		"S-1-5-32-555": "Remote Desktop Users",
+		"S-1-5-32-562": "Distributed COM Users",
+		"S-1-5-32-568": "IIS_IUSRS",
+		"S-1-5-32-573": "Event Log Readers",
+		"S-1-5-80-0":   "All Services",
+		"S-1-15-2-1":   "ALL APPLICATION PACKAGES",
+		"S-1-16-4096":  "Low Mandatory Level",
+		"S-1-16-8192":  "Medium Mandatory Level",
+		"S-1-16-12288": "High Mandatory Level",
+		"S-1-16-16384": "System Mandatory Level",
+	}
+)
+
+// sidResolver maps SIDs to account names learned from ProfileList, the SAM
+// hive and the well-known SIDs. Names are learned as entities stream in, so
+// lookups are safe while a scan is still running.
+type sidResolver struct {
+	mu       sync.RWMutex
+	names    map[string]string
+	sids     map[string]string
+	domains  map[string]string
+	accounts map[string]*samAccountName
+}
+
+type samAccountName struct {
+	root     string
+	rid      string
+	username string
+}
+
+func newSidResolver(regs []*entities.Registry) *sidResolver {
+
+	r := &sidResolver{
+		names:    make(map[string]string),
+		sids:     make(map[string]string),
+		domains:  make(map[string]string),
+		accounts: make(map[string]*samAccountName),
+	}
+
+	for _, reg := range regs {
+		r.learn(reg)
+	}
+
+	return r
+}
+
+// learn records the account name an entity reveals, if any.
+func (r *sidResolver) learn(reg *entities.Registry) {
+
+	switch {
+
+	case reg.Name == "ProfileImagePath" && utils.HasPrefixFold(reg.Path, STR_PROFILE_LIST):
+		sid := reg.Path[len(STR_PROFILE_LIST):]
+		if !strings.Contains(sid, "\\") {
+			r.add(sid, reg.Value[strings.LastIndex(reg.Value, "\\")+1:], false)
+		}
+
+	case reg.Name == "V" && strings.HasSuffix(strings.ToLower(reg.Path), strings.ToLower(STR_SAM_ACCOUNT_DOMAIN)):
+		data, err := decoders.RawData(reg)
+		if err != nil {
+			return
+		}
+		if sid, err := decoders.ParseSAMDomainSID(data); err == nil {
+			r.mu.Lock()
+			r.domains[strings.ToLower(reg.Path[:len(reg.Path)-len(STR_SAM_ACCOUNT_DOMAIN)])] = sid
+			r.mu.Unlock()
+			r.resolveAccounts()
+		}
+
+	case reg.Decoded != nil && reg.Decoded.Decoder == decoders.DECODER_SAM_ACCOUNT:
+		root := strings.ToLower(reg.Path)
+		i := strings.Index(root, strings.ToLower(STR_SAM_ACCOUNT_USERS))
+		if i < 0 {
+			return
+		}
+		r.mu.Lock()
+		account, ok := r.accounts[root]
+		if !ok {
+			account = &samAccountName{root: root[:i]}
+			r.accounts[root] = account
+		}
+		for _, f := range reg.Decoded.Fields {
+			switch f.Name {
+			case "RID":
+				account.rid = f.Value
+			case "Username":
+				account.username = f.Value
+			}
+		}
+		r.mu.Unlock()
+		r.resolveAccounts()
+	}
+}
+
+func (r *sidResolver) resolveAccounts() {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, account := range r.accounts {
+		domain, ok := r.domains[account.root]
+		if !ok || account.rid == "" || account.username == "" {
+			continue
+		}
+		r.addLocked(domain+"-"+account.rid, account.username, true)
+		delete(r.accounts, key)
+	}
+}
+
+func (r *sidResolver) add(sid string, name string, override bool) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.addLocked(sid, name, override)
+}
+
+func (r *sidResolver) addLocked(sid string, name string, override bool) {
+
+	sid = strings.ToUpper(sid)
+	if _, ok := r.names[sid]; ok && !override {
+		return
+	}
+	r.names[sid] = name
+	if _, ok := r.sids[strings.ToLower(name)]; !ok {
+		r.sids[strings.ToLower(name)] = sid
+	}
+}
+
+func (r *sidResolver) name(sid string) string {
+
+	sid = strings.ToUpper(sid)
+	if name, ok := wellKnownSids[sid]; ok {
+		return name
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.names[sid]
+}
+
+func (r *sidResolver) sid(name string) string {
+
+	r.mu.RLock()
+	sid, ok := r.sids[strings.ToLower(name)]
+	r.mu.RUnlock()
+
+	if ok {
+		return sid
+	}
+
+	for sid, wellKnown := range wellKnownSids {
+		if strings.EqualFold(wellKnown, name) {
+			return sid
+		}
+	}
+	return ""
+}
+
+// display rewrites every SID in s according to mode, leaving unknown SIDs as is.
+func (r *sidResolver) display(s string, mode string) string {
+
+	if mode != utils.STR_SID_DISPLAY_ANNOTATE && mode != utils.STR_SID_DISPLAY_SUBSTITUTE {
+		return s
+	}
+
+	return sidPattern.ReplaceAllStringFunc(s, func(sid string) string {
+		name := r.name(sid)
+		switch {
+		case name == "":
+			return sid
+		case mode == utils.STR_SID_DISPLAY_SUBSTITUTE:
+			return name
+		default:
+			return sid + " (" + name + ")"
+		}
+	})
+}
+
+// expandUserPath turns HKEY_USERS\<username> and HKEY_USERS\<username>_Classes
+// back into the SID keys they stand for.
+func (r *sidResolver) expandUserPath(path string) string {
+
+	if !utils.HasPrefixFold(path, utils.STR_HKEY_USERS+"\\") {
+		return path
+	}
+
+	user, rest, _ := strings.Cut(path[len(utils.STR_HKEY_USERS)+1:], "\\")
+	name, suffix := user, ""
+	if trimmed, found := strings.CutSuffix(user, STR_USER_CLASSES_SUFFIX); found {
+		name, suffix = trimmed, STR_USER_CLASSES_SUFFIX
+	}
+
+	sid := r.sid(name)
+	if sid == "" {
+		return path
+	}
+
+	expanded := utils.STR_HKEY_USERS + "\\" + sid + suffix
+	if rest != "" || strings.HasSuffix(path, "\\") {
+		expanded += "\\" + rest
+	}
+	return expanded
+}
@@ -0,0 +1,137 @@
+package usecases
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/decoders"
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_TASKS string = "Scheduled Tasks"
+
+	STR_TASKCACHE_TREE  string = "\\Schedule\\TaskCache\\Tree\\"
+	STR_TASKCACHE_TASKS string = "\\Schedule\\TaskCache\\Tasks\\"
+
+	STR_TASK_FINDING_NO_SD   string = "No SD (hidden)"
+	STR_TASK_FINDING_NO_TREE string = "No Tree entry"
+	STR_TASK_FINDING_NO_TASK string = "No Tasks entry"
+)
+
+// taskRef identifies a task by the lower case path of the Schedule key
+// holding it and its upper case GUID.
+type taskRef struct {
+	root string
+	id   string
+}
+
+var taskReportColumns = []string{"Task", "Id", "Finding", "Actions", "Run As", "Author", "Created", "Last Run", "Last Successful Run", "Last Result", "Index", "Tree Last Write", "Registry Key"}
+
+// TaskCacheReport joins the TaskCache Tree and Tasks keys into one row per
+// scheduled task. Tasks whose Tree entry lost its SD value are hidden from
+// the Task Scheduler and are flagged, as are entries missing on either side.
+func (u *RegistryUsecaseImpl) TaskCacheReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_TASKS, Columns: taskReportColumns}
+
+	idx := newKeyIndex(regs, func(path string) bool {
+		lower := strings.ToLower(path)
+		return strings.Contains(lower, strings.ToLower(STR_TASKCACHE_TREE)) || strings.Contains(lower, strings.ToLower(STR_TASKCACHE_TASKS))
+	})
+
+	paths := make([]string, 0, len(idx.keys))
+	for _, k := range idx.keys {
+		paths = append(paths, k.path)
+	}
+	sort.Strings(paths)
+
+	// entries are joined within the hive holding them, so a RegBack copy of
+	// a task never pairs with the current hive's half
+	tree := make(map[taskRef]*indexedKey)
+	tasks := make(map[taskRef]*indexedKey)
+	refs := make([]taskRef, 0)
+	for _, path := range paths {
+		k := idx.get(path)
+		lower := strings.ToLower(path)
+		var ref taskRef
+		switch {
+		case strings.Contains(lower, strings.ToLower(STR_TASKCACHE_TREE)) && k.valueString("Id") != "":
+			ref = taskRef{root: lower[:strings.Index(lower, strings.ToLower(STR_TASKCACHE_TREE))], id: strings.ToUpper(k.valueString("Id"))}
+			if tree[ref] != nil {
+				continue
+			}
+			if tasks[ref] == nil {
+				refs = append(refs, ref)
+			}
+			tree[ref] = k
+		case utils.MatchPathPattern(decoders.STR_TASKCACHE_TASKS_PATTERN, path):
+			ref = taskRef{root: lower[:strings.Index(lower, strings.ToLower(STR_TASKCACHE_TASKS))], id: strings.ToUpper(k.name())}
+			if tree[ref] == nil {
+				refs = append(refs, ref)
+			}
+			tasks[ref] = k
+		}
+	}
+
+	for _, ref := range refs {
+
+		id := ref.id
+		treeKey, taskKey := tree[ref], tasks[ref]
+		findings := make([]string, 0)
+		name, index, treeLastWrite, keyPath := "", "", "", ""
+
+		if treeKey != nil {
+			path := treeKey.path
+			name = path[strings.Index(strings.ToLower(path), strings.ToLower(STR_TASKCACHE_TREE))+len(STR_TASKCACHE_TREE)-1:]
+			if v, err := strconv.ParseUint(treeKey.valueString("Index"), 0, 32); err == nil {
+				index = strconv.FormatUint(v, 10)
+			}
+			if treeKey.value("SD") == nil {
+				findings = append(findings, STR_TASK_FINDING_NO_SD)
+			}
+			treeLastWrite = utils.FormatTime(treeKey.lastWrite())
+			keyPath = treeKey.path
+		} else {
+			findings = append(findings, STR_TASK_FINDING_NO_TREE)
+		}
+
+		row := []string{name, id, "", "", "", "", "", "", "", "", index, treeLastWrite, keyPath}
+
+		if taskKey != nil {
+			if name == "" {
+				row[0] = taskKey.valueString("Path")
+			}
+			if data, ok := rawValue(taskKey, "Actions"); ok {
+				if ta, err := decoders.ParseTaskActions(data); ta != nil && (err == nil || len(ta.Actions) > 0) {
+					row[3], row[4] = ta.String(), ta.Context
+				}
+			}
+			row[5] = taskKey.valueString("Author")
+			if data, ok := rawValue(taskKey, "DynamicInfo"); ok {
+				if info, err := decoders.ParseTaskDynamicInfo(data); err == nil {
+					row[6], row[7], row[8] = utils.FormatTime(info.Created), utils.FormatTime(info.LastRun), utils.FormatTime(info.LastSuccessfulRun)
+					row[9] = fmt.Sprintf("0x%08x", info.LastResult)
+				}
+			}
+			row[12] = taskKey.path
+		} else {
+			findings = append(findings, STR_TASK_FINDING_NO_TASK)
+		}
+
+		row[2] = strings.Join(findings, ", ")
+		report.Rows = append(report.Rows, row)
+	}
+
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		if (report.Rows[i][2] != "") != (report.Rows[j][2] != "") {
+			return report.Rows[i][2] != ""
+		}
+		return strings.ToLower(report.Rows[i][0]) < strings.ToLower(report.Rows[j][0])
+	})
+
+	return report
+}
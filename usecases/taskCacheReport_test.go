@@ -0,0 +1,47 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/repositories"
+)
+
+const STR_TEST_TASK_ID string = "{00000000-0000-0000-0000-000000000001}"
+
+func TestTaskCacheReportJoinsWithinHive(t *testing.T) {
+
+	u := &RegistryUsecaseImpl{sids: newSidResolver(nil), guids: newGuidResolver()}
+	schedule := "\\Microsoft\\Windows NT\\CurrentVersion\\Schedule\\TaskCache"
+	current := repositories.IMAGE_MOUNT_SOFTWARE + schedule
+	backup := repositories.IMAGE_MOUNT_REGBACK + "\\SOFTWARE" + schedule
+
+	regs := []*entities.Registry{
+		testValue(current+"\\Tree\\Updater", "Id", STR_TEST_TASK_ID),
+		testValue(current+"\\Tree\\Updater", "SD", "x"),
+		testValue(current+"\\Tasks\\"+STR_TEST_TASK_ID, "Author", "current"),
+		testValue(backup+"\\Tree\\Old Updater", "Id", STR_TEST_TASK_ID),
+		testValue(backup+"\\Tree\\Old Updater", "SD", "x"),
+		testValue(backup+"\\Tasks\\"+STR_TEST_TASK_ID, "Author", "backup"),
+		// a second Tree entry for the same id loses to the first by path
+		testValue(backup+"\\Tree\\Updater Copy", "Id", STR_TEST_TASK_ID),
+	}
+
+	want := [][]string{
+		{"\\Old Updater", "backup", backup + "\\Tasks\\" + STR_TEST_TASK_ID},
+		{"\\Updater", "current", current + "\\Tasks\\" + STR_TEST_TASK_ID},
+	}
+
+	// map iteration changes between runs, the joins must not
+	for run := 0; run < 20; run++ {
+		report := u.TaskCacheReport(regs)
+		if len(report.Rows) != len(want) {
+			t.Fatalf("run %d: %d rows, want %d", run, len(report.Rows), len(want))
+		}
+		for i, row := range report.Rows {
+			if row[0] != want[i][0] || row[5] != want[i][1] || row[12] != want[i][2] || row[2] != "" {
+				t.Errorf("run %d: row %d = %q %q %q finding %q, want %q", run, i, row[0], row[5], row[12], row[2], want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package usecases
+
+import (
+	"sort"
+
+	"github.com/0736b/registry-finder-gui/entities"
+)
+
+// Timeline gathers the events decoders recovered from the given entities in
+// chronological order.
+func (u *RegistryUsecaseImpl) Timeline(regs []*entities.Registry) []*entities.TimelineEvent {
+
+	events := make([]*entities.TimelineEvent, 0)
+	for _, reg := range regs {
+		if reg.Decoded != nil {
+			events = append(events, reg.Decoded.Events...)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	return events
+}
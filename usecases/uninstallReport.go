@@ -0,0 +1,118 @@
+package usecases
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_UNINSTALL string = "Installed Software"
+
+	STR_UNINSTALL_PATTERN       string = "**\\Software\\Microsoft\\Windows\\CurrentVersion\\Uninstall\\*"
+	STR_UNINSTALL_WOW64_PATTERN string = "**\\Software\\Wow6432Node\\Microsoft\\Windows\\CurrentVersion\\Uninstall\\*"
+
+	STR_SCOPE_MACHINE string = "Machine"
+	STR_SCOPE_USER    string = "User"
+	STR_ARCH_32       string = "32-bit"
+	STR_ARCH_64       string = "64-bit"
+)
+
+var (
+	uninstallReportColumns = []string{"Name", "Version", "Publisher", "Install Date", "Install Location", "Uninstall String", "Architecture", "Scope", "User", "Suspicious", "Key Last Write", "Registry Key"}
+
+	installDateLayouts = []string{"20060102", "1/2/2006", "01/02/2006", "2006-01-02", "2006/01/02"}
+)
+
+// UninstallReport merges the machine wide 64 and 32 bit Uninstall keys with
+// every user's into one inventory. Entries without a DisplayName, such as
+// update fragments, are left out as Programs and Features does.
+func (u *RegistryUsecaseImpl) UninstallReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_UNINSTALL, Columns: uninstallReportColumns}
+
+	idx := newKeyIndex(regs, func(path string) bool {
+		return utils.MatchPathPattern(STR_UNINSTALL_PATTERN, path) || utils.MatchPathPattern(STR_UNINSTALL_WOW64_PATTERN, path)
+	})
+
+	paths := make([]string, 0, len(idx.keys))
+	for _, k := range idx.keys {
+		paths = append(paths, k.path)
+	}
+	sort.Strings(paths)
+	deduper := newUserPathDeduper(paths)
+
+	for _, path := range paths {
+
+		k := idx.get(path)
+		name := k.valueString("DisplayName")
+		if name == "" {
+			continue
+		}
+
+		user, ok := deduper.keep(path)
+		if !ok {
+			continue
+		}
+
+		scope, arch := STR_SCOPE_MACHINE, STR_ARCH_64
+		if user != "" {
+			scope, arch = STR_SCOPE_USER, ""
+		}
+		if utils.MatchPathPattern(STR_UNINSTALL_WOW64_PATTERN, path) {
+			arch = STR_ARCH_32
+		}
+
+		location := k.valueString("InstallLocation")
+		uninstall := k.valueString("UninstallString")
+
+		suspicious := make([]string, 0)
+		for _, p := range []string{location, utils.CommandBinary(uninstall)} {
+			if reason := utils.SuspiciousPathReason(p); reason != "" && !slices.Contains(suspicious, reason) {
+				suspicious = append(suspicious, reason)
+			}
+		}
+
+		report.Rows = append(report.Rows, []string{
+			name, k.valueString("DisplayVersion"), k.valueString("Publisher"), parseInstallDate(k.valueString("InstallDate")),
+			location, uninstall, arch, scope, u.sids.display(user, utils.STR_SID_DISPLAY_ANNOTATE),
+			strings.Join(suspicious, ", "), utils.FormatTime(k.lastWrite()), path,
+		})
+	}
+
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		return strings.ToLower(report.Rows[i][0]) < strings.ToLower(report.Rows[j][0])
+	})
+
+	return report
+}
+
+// parseInstallDate reads the yyyymmdd string most installers write, the
+// locale formats some others use and DWORD Unix timestamps.
+func parseInstallDate(s string) string {
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+
+	if strings.HasPrefix(s, "0x") {
+		if v, err := strconv.ParseUint(s, 0, 32); err == nil && v > 0 {
+			return time.Unix(int64(v), 0).UTC().Format(time.DateOnly)
+		}
+		return s
+	}
+
+	for _, layout := range installDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(time.DateOnly)
+		}
+	}
+
+	return s
+}
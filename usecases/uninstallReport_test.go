@@ -0,0 +1,42 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+func TestUninstallReportSuspicious(t *testing.T) {
+
+	u := &RegistryUsecaseImpl{sids: newSidResolver(nil), guids: newGuidResolver()}
+	uninstall := utils.STR_HKEY_LOCAL_MACHINE + "\\SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\Uninstall\\"
+
+	tests := []struct {
+		name      string
+		location  string
+		uninstall string
+		want      string
+	}{
+		{"program files", "C:\\Program Files\\Tool", "\"C:\\Program Files\\Tool\\uninstall.exe\" /S", ""},
+		{"profile folder", "C:\\Users\\bob\\Tools", "C:\\Users\\bob\\Tools\\uninstall.exe", "User profile"},
+		{"package cache", "", "\"C:\\ProgramData\\Package Cache\\{00000000-0000-0000-0000-000000000001}\\setup.exe\" /uninstall", ""},
+		{"programdata vendor folder", "", "C:\\ProgramData\\Tool\\uninstall.exe", "ProgramData"},
+	}
+
+	for _, tt := range tests {
+		key := uninstall + tt.name
+		report := u.UninstallReport([]*entities.Registry{
+			testValue(key, "DisplayName", tt.name),
+			testValue(key, "InstallLocation", tt.location),
+			testValue(key, "UninstallString", tt.uninstall),
+		})
+		if len(report.Rows) != 1 {
+			t.Errorf("%s: %d rows, want 1", tt.name, len(report.Rows))
+			continue
+		}
+		if got := report.Rows[0][9]; got != tt.want {
+			t.Errorf("%s: suspicious %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package usecases
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0736b/registry-finder-gui/decoders"
+	"github.com/0736b/registry-finder-gui/entities"
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_REPORT_USB string = "USB Devices"
+
+	STR_USBSTOR_PATTERN      string = "HKEY_LOCAL_MACHINE\\SYSTEM\\*\\Enum\\USBSTOR\\*\\*"
+	STR_USB_PATTERN          string = "HKEY_LOCAL_MACHINE\\SYSTEM\\*\\Enum\\USB\\*\\*"
+	STR_MOUNTED_DEVICES      string = utils.STR_HKEY_LOCAL_MACHINE + "\\SYSTEM\\MountedDevices"
+	STR_WPD_DEVICES          string = utils.STR_HKEY_LOCAL_MACHINE + "\\SOFTWARE\\Microsoft\\Windows Portable Devices\\Devices"
+	STR_MOUNTPOINTS2_PATTERN string = "**\\Software\\Microsoft\\Windows\\CurrentVersion\\Explorer\\MountPoints2\\*"
+
+	// DEVPKEY_Device_FirstInstallDate, LastArrivalDate and LastRemovalDate
+	STR_DEVICE_PROPERTY_SET  string = "{83da6326-97a6-4088-9453-a1923f573b29}"
+	STR_DEVICE_FIRST_INSTALL string = "0064"
+	STR_DEVICE_LAST_ARRIVAL  string = "0066"
+	STR_DEVICE_LAST_REMOVAL  string = "0067"
+
+	STR_WPD_CLASS string = "WPD"
+)
+
+var usbReportColumns = []string{"Vendor", "Product", "Revision", "Serial", "VID/PID", "Friendly Name", "Volume Name", "Drive Letter", "Volume GUID", "First Connected", "Last Connected", "Last Removed", "Users", "Registry Key"}
+
+type usbDevice struct {
+	class        string
+	instance     string
+	key          *indexedKey
+	usbKey       *indexedKey
+	driveLetters []string
+	volumes      []string
+}
+
+// USBReport joins Enum\USBSTOR and Enum\USB with MountedDevices, Windows
+// Portable Devices and the users' MountPoints2 keys into one row per mass
+// storage or portable device. Times come from the device property keys.
+func (u *RegistryUsecaseImpl) USBReport(regs []*entities.Registry) *entities.Report {
+
+	report := &entities.Report{Title: STR_REPORT_USB, Columns: usbReportColumns}
+
+	idx := newKeyIndex(regs, func(path string) bool {
+		return utils.MatchPathPattern(STR_USBSTOR_PATTERN+"\\**", path) || utils.MatchPathPattern(STR_USB_PATTERN+"\\**", path) ||
+			strings.EqualFold(path, STR_MOUNTED_DEVICES) || utils.HasPrefixFold(path, STR_WPD_DEVICES) ||
+			utils.MatchPathPattern(STR_MOUNTPOINTS2_PATTERN, path)
+	})
+
+	paths := make([]string, 0, len(idx.keys))
+	for _, k := range idx.keys {
+		paths = append(paths, k.path)
+	}
+	sort.Strings(paths)
+
+	devices := make([]*usbDevice, 0)
+	byStorage := make(map[string]*usbDevice)
+	usbBySerial := make(map[string]*indexedKey)
+
+	for _, path := range paths {
+		k := idx.get(path)
+		class, instance := deviceClassInstance(path)
+
+		switch {
+		case utils.MatchPathPattern(STR_USBSTOR_PATTERN, path):
+			id := strings.ToLower(class + "\\" + instance)
+			if _, ok := byStorage[id]; ok {
+				continue
+			}
+			d := &usbDevice{class: class, instance: instance, key: k}
+			byStorage[id] = d
+			devices = append(devices, d)
+
+		case utils.MatchPathPattern(STR_USB_PATTERN, path):
+			if _, ok := usbBySerial[strings.ToLower(instance)]; !ok {
+				usbBySerial[strings.ToLower(instance)] = k
+			}
+		}
+	}
+
+	// portable devices such as phones and cameras have no USBSTOR key
+	mtpSeen := make(map[string]bool)
+	for _, path := range paths {
+		k := idx.get(path)
+		if !utils.MatchPathPattern(STR_USB_PATTERN, path) || !strings.EqualFold(k.valueString("Class"), STR_WPD_CLASS) {
+			continue
+		}
+		class, instance := deviceClassInstance(path)
+		if id := strings.ToLower(class + "\\" + instance); !mtpSeen[id] {
+			mtpSeen[id] = true
+			devices = append(devices, &usbDevice{class: class, instance: instance, key: k, usbKey: k})
+		}
+	}
+
+	for _, d := range devices {
+		if d.usbKey == nil {
+			d.usbKey = usbBySerial[strings.ToLower(usbSerial(d.instance))]
+		}
+	}
+
+	if mounted := idx.get(STR_MOUNTED_DEVICES); mounted != nil {
+		assignMountedDevices(mounted, byStorage)
+	}
+
+	mountUsers := u.volumeMountUsers(idx, paths)
+
+	for _, d := range devices {
+
+		vendor, product, revision := usbStorageIdentity(d.class)
+		vidPid := ""
+		if d.usbKey != nil {
+			vidPid, _ = deviceClassInstance(d.usbKey.path)
+		}
+
+		friendly := d.key.valueString("FriendlyName")
+		if friendly == "" {
+			friendly = d.key.valueString("DeviceDesc")
+			friendly = friendly[strings.LastIndex(friendly, ";")+1:]
+		}
+
+		users := make([]string, 0)
+		for _, volume := range d.volumes {
+			users = append(users, mountUsers[strings.ToLower(volume)]...)
+		}
+
+		report.Rows = append(report.Rows, []string{
+			vendor, product, revision, usbSerial(d.instance), vidPid, friendly, wpdVolumeName(idx, d),
+			strings.Join(d.driveLetters, ", "), strings.Join(d.volumes, ", "),
+			utils.FormatTime(d.propertyTime(STR_DEVICE_FIRST_INSTALL)), utils.FormatTime(d.propertyTime(STR_DEVICE_LAST_ARRIVAL)),
+			utils.FormatTime(d.propertyTime(STR_DEVICE_LAST_REMOVAL)), strings.Join(users, "; "), d.key.path,
+		})
+	}
+
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		return report.Rows[i][10] > report.Rows[j][10]
+	})
+
+	return report
+}
+
+// deviceClassInstance returns the last two segments of an Enum key path, the
+// device class (or VID/PID) and the instance.
+func deviceClassInstance(path string) (string, string) {
+
+	segments := strings.Split(path, "\\")
+	if len(segments) < 2 {
+		return "", path
+	}
+	return segments[len(segments)-2], segments[len(segments)-1]
+}
+
+// usbStorageIdentity splits a USBSTOR device class such as
+// Disk&Ven_SanDisk&Prod_Cruzer&Rev_1.00 into vendor, product and revision.
+func usbStorageIdentity(class string) (string, string, string) {
+
+	var vendor, product, revision string
+	for _, part := range strings.Split(class, "&") {
+		switch {
+		case utils.HasPrefixFold(part, "Ven_"):
+			vendor = part[len("Ven_"):]
+		case utils.HasPrefixFold(part, "Prod_"):
+			product = part[len("Prod_"):]
+		case utils.HasPrefixFold(part, "Rev_"):
+			revision = part[len("Rev_"):]
+		}
+	}
+	return vendor, product, revision
+}
+
+// usbSerial drops the &<n> interface suffix Windows appends to the serial.
+// Instances whose second character is & carry a serial Windows generated
+// because the device reported none.
+func usbSerial(instance string) string {
+
+	if len(instance) > 1 && instance[1] == '&' {
+		return instance
+	}
+	if i := strings.LastIndex(instance, "&"); i > 0 {
+		return instance[:i]
+	}
+	return instance
+}
+
+// propertyTime reads a FILETIME device property from the USBSTOR key, or
+// from the USB parent when the storage key lacks it. Windows 7 keeps the data
+// one level deeper, in 000000xx\00000000\Data.
+func (d *usbDevice) propertyTime(id string) time.Time {
+
+	for _, k := range []*indexedKey{d.key, d.usbKey} {
+
+		if k == nil || k.child("Properties") == nil {
+			continue
+		}
+		set := k.child("Properties").child(STR_DEVICE_PROPERTY_SET)
+		if set == nil {
+			continue
+		}
+
+		for _, name := range []string{id, "0000" + id} {
+			p := set.child(name)
+			if p == nil {
+				continue
+			}
+			if data, ok := rawValue(p, ""); ok {
+				if t, err := decoders.ParseFiletime(data); err == nil {
+					return t
+				}
+			}
+			if sub := p.child("00000000"); sub != nil {
+				if data, ok := rawValue(sub, "Data"); ok {
+					if t, err := decoders.ParseFiletime(data); err == nil {
+						return t
+					}
+				}
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+// assignMountedDevices attaches the drive letters and volume GUIDs whose
+// MountedDevices data names a USBSTOR device.
+func assignMountedDevices(mounted *indexedKey, byStorage map[string]*usbDevice) {
+
+	for _, v := range mounted.values {
+
+		data, err := decoders.RawData(v)
+		if err != nil {
+			continue
+		}
+
+		target := strings.ToLower(utils.UTF16ToString(data))
+		i := strings.Index(target, "usbstor#")
+		if i < 0 {
+			continue
+		}
+		parts := strings.Split(target[i:], "#")
+		if len(parts) < 3 {
+			continue
+		}
+
+		d, ok := byStorage[parts[1]+"\\"+parts[2]]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case utils.HasPrefixFold(v.Name, "\\DosDevices\\"):
+			d.driveLetters = append(d.driveLetters, v.Name[len("\\DosDevices\\"):])
+		case utils.HasPrefixFold(v.Name, "\\??\\Volume"):
+			d.volumes = append(d.volumes, v.Name[len("\\??\\Volume"):])
+		}
+	}
+}
+
+// volumeMountUsers maps each volume GUID to the users whose MountPoints2 key
+// holds it, with the key's last write as the time of their last mount.
+func (u *RegistryUsecaseImpl) volumeMountUsers(idx *keyIndex, paths []string) map[string][]string {
+
+	users := make(map[string][]string)
+	deduper := newUserPathDeduper(paths)
+
+	for _, path := range paths {
+
+		if !utils.MatchPathPattern(STR_MOUNTPOINTS2_PATTERN, path) {
+			continue
+		}
+		user, ok := deduper.keep(path)
+		if !ok || user == "" {
+			continue
+		}
+
+		k := idx.get(path)
+		volume := strings.ToLower(k.name())
+		users[volume] = append(users[volume], u.sids.display(user, utils.STR_SID_DISPLAY_ANNOTATE)+" at "+utils.FormatTime(k.lastWrite()))
+	}
+
+	return users
+}
+
+// wpdVolumeName returns the name Windows Portable Devices recorded for the
+// device, usually the volume label or the phone's name.
+func wpdVolumeName(idx *keyIndex, d *usbDevice) string {
+
+	wpd := idx.get(STR_WPD_DEVICES)
+	if wpd == nil {
+		return ""
+	}
+
+	needle := "#" + strings.ToLower(d.instance) + "#"
+	for _, k := range wpd.children {
+		if strings.Contains(strings.ToLower(k.name()), needle) {
+			return k.valueString("FriendlyName")
+		}
+	}
+	return ""
+}
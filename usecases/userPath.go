@@ -0,0 +1,78 @@
+package usecases
+
+import (
+	"strings"
+
+	"github.com/0736b/registry-finder-gui/utils"
+)
+
+const (
+	STR_USER_CLASSES_SUFFIX string = "_Classes"
+	STR_SOFTWARE_CLASSES    string = "Software\\Classes"
+)
+
+// splitUserPath returns the user hive a path belongs to (a SID, or
+// HKEY_CURRENT_USER) and the path relative to it, with the classes hive
+// folded under Software\Classes so both views of it compare equal.
+func splitUserPath(path string) (string, string, bool) {
+
+	if utils.HasPrefixFold(path, utils.STR_HKEY_CURRENT_USER+"\\") {
+		return utils.STR_HKEY_CURRENT_USER, path[len(utils.STR_HKEY_CURRENT_USER)+1:], true
+	}
+
+	if !utils.HasPrefixFold(path, utils.STR_HKEY_USERS+"\\") {
+		return "", "", false
+	}
+
+	user, rel, _ := strings.Cut(path[len(utils.STR_HKEY_USERS)+1:], "\\")
+	if sid, found := strings.CutSuffix(user, STR_USER_CLASSES_SUFFIX); found {
+		return sid, STR_SOFTWARE_CLASSES + "\\" + rel, true
+	}
+
+	return user, rel, true
+}
+
+// userPathDeduper drops keys reached twice through different views of the
+// same user hive: HKCU next to HKU\<SID>, or Software\Classes next to the
+// <SID>_Classes hive. HKEY_CLASSES_ROOT is always a derived view.
+type userPathDeduper struct {
+	seen    map[string]bool
+	hkuRels map[string]bool
+}
+
+func newUserPathDeduper(paths []string) *userPathDeduper {
+
+	d := &userPathDeduper{seen: make(map[string]bool), hkuRels: make(map[string]bool)}
+	for _, path := range paths {
+		if user, rel, ok := splitUserPath(path); ok && user != utils.STR_HKEY_CURRENT_USER {
+			d.hkuRels[strings.ToLower(rel)] = true
+		}
+	}
+	return d
+}
+
+// keep reports whether path is the first view of its key and returns the
+// user the key belongs to.
+func (d *userPathDeduper) keep(path string) (string, bool) {
+
+	if utils.HasPrefixFold(path, utils.STR_HKEY_CLASSES_ROOT) {
+		return "", false
+	}
+
+	user, rel, ok := splitUserPath(path)
+	if !ok {
+		return "", true
+	}
+
+	if user == utils.STR_HKEY_CURRENT_USER && d.hkuRels[strings.ToLower(rel)] {
+		return "", false
+	}
+
+	k := strings.ToLower(user + "\\" + rel)
+	if d.seen[k] {
+		return "", false
+	}
+	d.seen[k] = true
+
+	return user, true
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const (
+	STR_DEFAULT_SYSTEM_ROOT string = "C:\\Windows"
+)
+
+var (
+	systemRootPrefixes = []string{"\\SystemRoot\\", "%SystemRoot%\\", "%windir%\\"}
+	systemRelativeDirs = []string{"System32\\", "SysWOW64\\"}
+
+	executableExts = []string{".exe", ".dll", ".sys", ".com", ".scr", ".cpl", ".ocx", ".bat", ".cmd", ".ps1", ".vbs", ".vbe", ".js", ".jse", ".wsf", ".hta", ".msi", ".jar"}
+	dllLoaders     = []string{"rundll32.exe", "rundll32", "regsvr32.exe", "regsvr32"}
+)
+
+// CommandBinary extracts the file a command line runs. Unquoted paths with
+// spaces are resolved by looking for the first executable extension, and for
+// rundll32 and regsvr32 the loaded DLL is returned instead of the loader.
+func CommandBinary(cmd string) string {
+
+	binary, rest := splitCommand(strings.TrimSpace(cmd))
+
+	for _, loader := range dllLoaders {
+		if strings.EqualFold(filepath.Base(strings.ReplaceAll(binary, "\\", "/")), loader) {
+			for _, arg := range strings.Fields(rest) {
+				if !strings.HasPrefix(arg, "/") && !strings.HasPrefix(arg, "-") {
+					dll, _ := splitCommand(strings.TrimSpace(rest[strings.Index(rest, arg):]))
+					dll, _, _ = strings.Cut(dll, ",")
+					return dll
+				}
+			}
+			break
+		}
+	}
+
+	return binary
+}
+
+// NormalizeImagePath rewrites the forms a service ImagePath takes, \\SystemRoot\\,
+// %SystemRoot%, \\??\\ and paths relative to the Windows directory, into
+// paths under systemRoot. Empty systemRoot stands for C:\\Windows.
+func NormalizeImagePath(imagePath string, systemRoot string) string {
+
+	if systemRoot == "" {
+		systemRoot = STR_DEFAULT_SYSTEM_ROOT
+	}
+	systemRoot = strings.TrimSuffix(systemRoot, "\\")
+
+	quote := ""
+	path := strings.TrimSpace(imagePath)
+	if strings.HasPrefix(path, "\"") {
+		quote, path = "\"", path[1:]
+	}
+
+	path = strings.TrimPrefix(path, "\\??\\")
+
+	for _, prefix := range systemRootPrefixes {
+		if HasPrefixFold(path, prefix) {
+			return quote + systemRoot + "\\" + path[len(prefix):]
+		}
+	}
+
+	for _, dir := range systemRelativeDirs {
+		if HasPrefixFold(path, dir) {
+			return quote + systemRoot + "\\" + path
+		}
+	}
+
+	return quote + path
+}
+
+func splitCommand(cmd string) (string, string) {
+
+	if strings.HasPrefix(cmd, "\"") {
+		if end := strings.Index(cmd[1:], "\""); end >= 0 {
+			return cmd[1 : end+1], cmd[end+2:]
+		}
+		return strings.Trim(cmd, "\""), ""
+	}
+
+	// bare program names are looked up on PATH and cannot contain spaces
+	first, rest, _ := strings.Cut(cmd, " ")
+	if !strings.ContainsAny(first, "\\/") {
+		return strings.TrimSuffix(first, ","), rest
+	}
+
+	for i := 0; i < len(cmd); i++ {
+		if cmd[i] != ' ' && cmd[i] != ',' && i != len(cmd)-1 {
+			continue
+		}
+		end := i
+		if i == len(cmd)-1 && cmd[i] != ' ' && cmd[i] != ',' {
+			end = len(cmd)
+		}
+		if hasExecutableExt(cmd[:end]) {
+			return cmd[:end], cmd[end:]
+		}
+	}
+
+	return first, rest
+}
+
+func hasExecutableExt(path string) bool {
+
+	lower := strings.ToLower(path)
+	for _, ext := range executableExts {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
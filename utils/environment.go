@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// ENV_EXPANDED_MAX_LEN is the longest result ExpandEnvironmentStrings
+	// returns, in characters.
+	ENV_EXPANDED_MAX_LEN    int = 32767
+	ENV_EXPANSION_MAX_DEPTH int = 32
+	ENV_EXPANSION_MAX_REFS  int = 4096
+)
+
+// ExpandEnvironment replaces the %NAME% references in s with what lookup
+// returns for them, expanding those values in turn. Unknown names are kept
+// as written, as ExpandEnvironmentStrings does. A variable that refers back
+// to itself is left unexpanded and reported as an error naming the cycle.
+// Values nesting deeper than ENV_EXPANSION_MAX_DEPTH, referring to more than
+// ENV_EXPANSION_MAX_REFS variables in all or growing past
+// ENV_EXPANDED_MAX_LEN stop expanding there with an error as well.
+func ExpandEnvironment(s string, lookup func(name string) (string, bool)) (string, error) {
+
+	e := &environmentExpansion{lookup: lookup}
+	expanded := e.expand(s, nil)
+	if len(expanded) > ENV_EXPANDED_MAX_LEN {
+		expanded = expanded[:ENV_EXPANDED_MAX_LEN]
+	}
+	return expanded, e.err
+}
+
+// environmentExpansion holds what one ExpandEnvironment call shares across
+// its nested expansions: the reference count and the first error.
+type environmentExpansion struct {
+	lookup func(name string) (string, bool)
+	refs   int
+	err    error
+}
+
+func (e *environmentExpansion) fail(err error) {
+
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *environmentExpansion) expand(s string, stack []string) string {
+
+	var sb strings.Builder
+
+	for {
+		start := strings.Index(s, "%")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start+1:], "%")
+		if end < 0 {
+			break
+		}
+
+		name := s[start+1 : start+1+end]
+		sb.WriteString(s[:start])
+
+		value, ok := e.lookup(name)
+		if name == "" || !ok {
+			// the closing % may open the next reference
+			sb.WriteString("%" + name)
+			s = s[start+1+end:]
+			continue
+		}
+		s = s[start+2+end:]
+
+		if cycle := environmentCycle(stack, name); cycle != "" {
+			e.fail(fmt.Errorf("environment variable cycle %s", cycle))
+			sb.WriteString("%" + name + "%")
+			continue
+		}
+
+		e.refs++
+		switch {
+		case len(stack) >= ENV_EXPANSION_MAX_DEPTH:
+			e.fail(fmt.Errorf("environment variables nest deeper than %d at %s", ENV_EXPANSION_MAX_DEPTH, name))
+		case e.refs > ENV_EXPANSION_MAX_REFS:
+			e.fail(fmt.Errorf("environment expansion refers to more than %d variables", ENV_EXPANSION_MAX_REFS))
+		case sb.Len() > ENV_EXPANDED_MAX_LEN:
+			e.fail(fmt.Errorf("environment expansion exceeds %d characters", ENV_EXPANDED_MAX_LEN))
+		default:
+			sb.WriteString(e.expand(value, append(stack, name)))
+			continue
+		}
+
+		// past a limit the rest stays as written
+		sb.WriteString("%" + name + "%" + s)
+		return sb.String()
+	}
+
+	sb.WriteString(s)
+	return sb.String()
+}
+
+func environmentCycle(stack []string, name string) string {
+
+	for i, s := range stack {
+		if strings.EqualFold(s, name) {
+			return strings.Join(append(stack[i:len(stack):len(stack)], name), " -> ")
+		}
+	}
+	return ""
+}
+
+// HasUnexpandedVariable reports whether s still holds a %NAME% reference.
+func HasUnexpandedVariable(s string) bool {
+
+	start := strings.Index(s, "%")
+	return start >= 0 && strings.Index(s[start+1:], "%") > 0
+}
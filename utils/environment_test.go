@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExpandEnvironment(t *testing.T) {
+
+	env := map[string]string{
+		"SYSTEMROOT": "C:\\Windows",
+		"WINDIR":     "%SystemRoot%",
+		"A":          "%B%",
+		"B":          "%A%",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[strings.ToUpper(name)]
+		return v, ok
+	}
+
+	tests := []struct {
+		s       string
+		want    string
+		wantErr bool
+	}{
+		{"%windir%\\System32", "C:\\Windows\\System32", false},
+		{"%unknown%\\%windir%", "%unknown%\\C:\\Windows", false},
+		{"100%", "100%", false},
+		{"%A%", "%A%", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ExpandEnvironment(tt.s, lookup)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ExpandEnvironment(%q) = %q, %v, want %q, error %v", tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestExpandEnvironmentLimits(t *testing.T) {
+
+	// every variable doubles the next one, V0 expands to 2^40 copies of V40
+	doubling := func(leaf string) func(string) (string, bool) {
+		return func(name string) (string, bool) {
+			var i int
+			if _, err := fmt.Sscanf(name, "V%d", &i); err != nil {
+				return "", false
+			}
+			if i == 40 {
+				return leaf, true
+			}
+			return fmt.Sprintf("%%V%d%%%%V%d%%", i+1, i+1), true
+		}
+	}
+	chain := func(name string) (string, bool) {
+		var i int
+		if _, err := fmt.Sscanf(name, "V%d", &i); err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("%%V%d%%", i+1), true
+	}
+
+	tests := []struct {
+		name   string
+		lookup func(string) (string, bool)
+	}{
+		{"exponential growth", doubling("C:\\Windows\\System32")},
+		{"exponential references to nothing", doubling("")},
+		{"unbounded nesting", chain},
+	}
+
+	for _, tt := range tests {
+		got, err := ExpandEnvironment("%V0%", tt.lookup)
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+		if len(got) > ENV_EXPANDED_MAX_LEN {
+			t.Errorf("%s: %d characters, want at most %d", tt.name, len(got), ENV_EXPANDED_MAX_LEN)
+		}
+	}
+}
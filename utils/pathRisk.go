@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+)
+
+var suspiciousDirs = []struct {
+	fragment string
+	reason   string
+}{
+	{"\\appdata\\local\\temp\\", "Temp directory"},
+	{"\\windows\\temp\\", "Temp directory"},
+	{"\\temp\\", "Temp directory"},
+	{"\\downloads\\", "Downloads directory"},
+	{"\\desktop\\", "Desktop directory"},
+	{"\\$recycle.bin\\", "Recycle Bin"},
+	{"\\users\\public\\", "Public profile"},
+	{"\\perflogs\\", "PerfLogs directory"},
+	{"\\appdata\\", "User profile AppData"},
+}
+
+// ProgramData subdirectories written by Windows components and installers
+// running elevated, e.g. Windows Defender\Platform or the Package Cache.
+var trustedProgramDataDirs = []string{"microsoft", "package cache"}
+
+// Profiles only administrators write to: the template new profiles are
+// copied from and its legacy junction.
+var trustedProfiles = []string{"default", "default user"}
+
+// SuspiciousPathReason reports why a file path lies in a directory ordinary
+// users can write to and malware commonly stages in, or "" when it does not.
+// Environment variables for those directories are recognized unexpanded.
+func SuspiciousPathReason(path string) string {
+
+	lower := strings.ToLower(strings.ReplaceAll(strings.Trim(path, "\" "), "/", "\\"))
+	if lower == "" {
+		return ""
+	}
+
+	for _, env := range []struct{ variable, dir string }{
+		{"%temp%", "\\temp\\"},
+		{"%tmp%", "\\temp\\"},
+		{"%appdata%", "\\appdata\\"},
+		{"%localappdata%", "\\appdata\\"},
+		{"%public%", "\\users\\public\\"},
+		{"%programdata%", "\\programdata\\"},
+		{"%userprofile%", "\\users\\profile\\"},
+	} {
+		if strings.HasPrefix(lower, env.variable) {
+			lower = env.dir + strings.TrimPrefix(lower[len(env.variable):], "\\")
+			break
+		}
+	}
+
+	// a trailing separator lets bare directories such as C:\Temp match
+	lower += "\\"
+	lower = strings.Replace(lower, "\\users\\all users\\", "\\programdata\\", 1)
+
+	for _, d := range suspiciousDirs {
+		if strings.Contains(lower, d.fragment) {
+			return d.reason
+		}
+	}
+
+	// files in the root or in third party folders, which inherit the
+	// creator-writable ACL of ProgramData itself
+	if _, rest, ok := strings.Cut(lower, "\\programdata\\"); ok {
+		dir, _, nested := strings.Cut(strings.TrimSuffix(rest, "\\"), "\\")
+		if dir != "" && (!nested || !slices.Contains(trustedProgramDataDirs, dir)) {
+			return "ProgramData"
+		}
+	}
+
+	// anything else inside a profile is the user's to write to
+	if _, rest, ok := strings.Cut(lower, "\\users\\"); ok {
+		profile, sub, _ := strings.Cut(strings.TrimSuffix(rest, "\\"), "\\")
+		if profile != "" && sub != "" && !slices.Contains(trustedProfiles, profile) {
+			return "User profile"
+		}
+	}
+
+	return ""
+}
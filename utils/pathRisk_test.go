@@ -0,0 +1,49 @@
+package utils
+
+import "testing"
+
+func TestSuspiciousPathReason(t *testing.T) {
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"C:\\Users\\bob\\AppData\\Local\\Temp\\a.exe", "Temp directory"},
+		{"C:\\Windows\\Temp", "Temp directory"},
+		{"C:\\Users\\bob\\AppData\\Roaming\\a.exe", "User profile AppData"},
+		{"C:\\Users\\bob\\Downloads\\setup.exe", "Downloads directory"},
+		{"C:\\Users\\bob\\Desktop\\a.exe", "Desktop directory"},
+		{"C:\\Users\\Public\\a.exe", "Public profile"},
+		{"C:\\ProgramData\\a.exe", "ProgramData"},
+		{"C:\\ProgramData\\Vendor\\Updater\\a.exe", "ProgramData"},
+		{"%ProgramData%\\a.exe", "ProgramData"},
+		{"C:\\ProgramData\\Package Cache\\{00000000-0000-0000-0000-000000000001}\\setup.exe", ""},
+		{"C:\\ProgramData\\Microsoft\\Windows Defender\\Platform\\4.18.2302.7-0\\MsMpEng.exe", ""},
+		{"C:\\ProgramData", ""},
+		{"\"C:\\$Recycle.Bin\\a.exe\"", "Recycle Bin"},
+		{"%TEMP%\\a.exe", "Temp directory"},
+		{"%LocalAppData%\\Programs\\a.exe", "User profile AppData"},
+		{"%USERPROFILE%\\Downloads\\a.exe", "Downloads directory"},
+		{"%userprofile%\\Desktop\\a.exe", "Desktop directory"},
+		{"%userprofile%\\AppData\\a.exe", "User profile AppData"},
+		{"%userprofile%\\Tools\\a.exe", "User profile"},
+		{"%USERPROFILE%\\a.exe", "User profile"},
+		{"%USERPROFILE%", ""},
+		{"C:\\Users\\bob\\Tools\\evil.dll", "User profile"},
+		{"C:\\Users\\bob\\a.exe", "User profile"},
+		{"C:\\Users\\bob", ""},
+		{"C:\\Users", ""},
+		{"C:\\Users\\Default\\NTUSER.DAT", ""},
+		{"C:\\Users\\All Users\\a.exe", "ProgramData"},
+		{"C:\\Users\\All Users\\Microsoft\\Windows\\a.exe", ""},
+		{"C:\\Program Files\\App\\a.exe", ""},
+		{"C:\\Windows\\System32\\a.dll", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := SuspiciousPathReason(tt.path); got != tt.want {
+			t.Errorf("SuspiciousPathReason(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
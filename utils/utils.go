@@ -2,12 +2,10 @@ package utils
 
 import (
 	"bytes"
-	"log"
-	"os/exec"
+	"encoding/binary"
 	"strings"
-	"syscall"
-
-	"golang.org/x/sys/windows/registry"
+	"time"
+	"unicode/utf16"
 )
 
 const (
@@ -18,6 +16,16 @@ const (
 	STR_HKEY_CURRENT_CONFIG string = "HKEY_CURRENT_CONFIG"
 )
 
+const (
+	STR_HKCR string = "HKCR"
+	STR_HKCU string = "HKCU"
+	STR_HKLM string = "HKLM"
+	STR_HKU  string = "HKU"
+	STR_HKCC string = "HKCC"
+
+	STR_COMPUTER_PREFIX string = "Computer\\"
+)
+
 const (
 	STR_EMPTY                          string = ""
 	STR_NONE                           string = "NONE"
@@ -32,10 +40,29 @@ const (
 	STR_REG_FULL_RESOURCE_DESCRIPTOR   string = "REG_FULL_RESOURCE_DESCRIPTOR"
 	STR_REG_RESOURCE_REQUIREMENTS_LIST string = "REG_RESOURCE_REQUIREMENTS_LIST"
 	STR_REG_QWORD                      string = "REG_QWORD"
+	STR_REG_DEVPROP                    string = "REG_DEVPROP"
+)
+
+const (
+	STR_POLICY_CLASS_MACHINE string = "Machine"
+	STR_POLICY_CLASS_USER    string = "User"
+	STR_POLICY_CLASS_BOTH    string = "Both"
+
+	STR_POLICY_VALUE_DECIMAL string = "decimal"
+	STR_POLICY_VALUE_STRING  string = "string"
+	STR_POLICY_VALUE_DELETE  string = "delete"
+)
+
+const (
+	STR_SID_DISPLAY_RAW        string = "Raw SIDs"
+	STR_SID_DISPLAY_ANNOTATE   string = "Annotate SIDs"
+	STR_SID_DISPLAY_SUBSTITUTE string = "Substitute SIDs"
 )
 
 const (
-	FLAG_CREATE_NO_WINDOW uint32 = 0x08000000
+	FILETIME_UNIX_EPOCH_DIFF uint64 = 116444736000000000
+
+	STR_TIME_LAYOUT string = "2006-01-02 15:04:05"
 )
 
 func BytesToString(b []byte) string {
@@ -47,91 +74,161 @@ func BytesToString(b []byte) string {
 	return strings.ReplaceAll(string(b[:n]), "\x00", "") // clean unexpected null characters
 }
 
-func GetTypeString(valType uint32) string {
-
-	switch valType {
-	case registry.SZ:
-		return STR_REG_SZ
-	case registry.EXPAND_SZ:
-		return STR_REG_EXPAND_SZ
-	case registry.BINARY:
-		return STR_REG_BINARY
-	case registry.DWORD:
-		return STR_REG_DWORD
-	case registry.DWORD_BIG_ENDIAN:
-		return STR_REG_DWORD_BIG_ENDIAN
-	case registry.LINK:
-		return STR_REG_LINK
-	case registry.MULTI_SZ:
-		return STR_REG_MULTI_SZ
-	case registry.RESOURCE_LIST:
-		return STR_REG_RESOURCE_LIST
-	case registry.FULL_RESOURCE_DESCRIPTOR:
-		return STR_REG_FULL_RESOURCE_DESCRIPTOR
-	case registry.RESOURCE_REQUIREMENTS_LIST:
-		return STR_REG_RESOURCE_REQUIREMENTS_LIST
-	case registry.QWORD:
-		return STR_REG_QWORD
-	case registry.NONE:
-		return STR_NONE
-	default:
-		return STR_EMPTY
+func UTF16ToString(b []byte) string {
+
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		c := binary.LittleEndian.Uint16(b[i:])
+		if c == 0 {
+			break
+		}
+		u = append(u, c)
+	}
+	return string(utf16.Decode(u))
+}
+
+func FiletimeToTime(ft uint64) time.Time {
+
+	if ft < FILETIME_UNIX_EPOCH_DIFF {
+		return time.Time{}
+	}
+	ft -= FILETIME_UNIX_EPOCH_DIFF
+	return time.Unix(int64(ft/10000000), int64(ft%10000000)*100).UTC()
+}
+
+// FormatTime renders timestamps in a sortable UTC form, empty for zero times.
+func FormatTime(t time.Time) string {
+
+	if t.IsZero() {
+		return ""
 	}
+	return t.UTC().Format(STR_TIME_LAYOUT)
 }
 
+// MultiSZToStringSlice splits REG_MULTI_SZ data on its NUL characters, read as
+// UTF-16 code units so the high byte of one character and the terminator
+// after it are not taken for a separator.
 func MultiSZToStringSlice(value []byte) []string {
 
 	var result []string
-	for _, s := range bytes.Split(value, []byte{0, 0}) {
-		if len(s) > 0 {
-			result = append(result, BytesToString(s))
+	start := 0
+	for i := 0; i+1 < len(value); i += 2 {
+		if value[i] == 0 && value[i+1] == 0 {
+			if i > start {
+				result = append(result, UTF16ToString(value[start:i]))
+			}
+			start = i + 2
 		}
 	}
+	if start+1 < len(value) {
+		result = append(result, UTF16ToString(value[start:]))
+	}
 	return result
 }
 
-func KeyToString(key registry.Key) string {
-
-	switch key {
-	case registry.CLASSES_ROOT:
-		return STR_HKEY_CLASSES_ROOT
-	case registry.CURRENT_USER:
-		return STR_HKEY_CURRENT_USER
-	case registry.LOCAL_MACHINE:
-		return STR_HKEY_LOCAL_MACHINE
-	case registry.USERS:
-		return STR_HKEY_USERS
-	case registry.CURRENT_CONFIG:
-		return STR_HKEY_CURRENT_CONFIG
+// NormalizeKeyPath expands abbreviated root keys (HKLM, HKCU, ...) and drops the
+// "Computer\" prefix regedit puts in front of copied key paths.
+func NormalizeKeyPath(path string) string {
+
+	if HasPrefixFold(path, STR_COMPUTER_PREFIX) {
+		path = path[len(STR_COMPUTER_PREFIX):]
+	}
+
+	root, rest := path, ""
+	if i := strings.Index(path, "\\"); i >= 0 {
+		root, rest = path[:i], path[i:]
+	}
+
+	switch strings.ToUpper(root) {
+	case STR_HKCR:
+		return STR_HKEY_CLASSES_ROOT + rest
+	case STR_HKCU:
+		return STR_HKEY_CURRENT_USER + rest
+	case STR_HKLM:
+		return STR_HKEY_LOCAL_MACHINE + rest
+	case STR_HKU:
+		return STR_HKEY_USERS + rest
+	case STR_HKCC:
+		return STR_HKEY_CURRENT_CONFIG + rest
 	default:
-		return STR_EMPTY
+		return path
 	}
 }
 
+func HasPrefixFold(s string, prefix string) bool {
+
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
 func PreProcessStr(s string) string {
 
 	return strings.ToLower(strings.ReplaceAll(s, " ", ""))
 }
 
-func OpenRegeditAtPath(path string) {
-
-	addLastKeyCmd := exec.Command("reg", "add", "HKCU\\Software\\Microsoft\\Windows\\CurrentVersion\\Applets\\Regedit", "/v", "LastKey", "/t", "REG_SZ", "/d", path, "/f")
-	addLastKeyCmd.SysProcAttr = &syscall.SysProcAttr{
-		CreationFlags: FLAG_CREATE_NO_WINDOW,
+// MatchWildcard reports whether s matches pattern ignoring ASCII case, where '*'
+// matches any run of characters and '?' matches a single character.
+func MatchWildcard(pattern string, s string) bool {
+
+	p, i := 0, 0
+	star, mark := -1, 0
+
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, i
+			p++
+		case p < len(pattern) && (pattern[p] == '?' || lowerASCII(pattern[p]) == lowerASCII(s[i])):
+			p++
+			i++
+		case star >= 0:
+			p = star + 1
+			mark++
+			i = mark
+		default:
+			return false
+		}
 	}
 
-	err := addLastKeyCmd.Run()
-	if err != nil {
-		log.Println("OpenRegeditAtPath failed to add last key", err.Error())
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
 	}
 
-	openRegeditCmd := exec.Command("cmd", "/c", "regedit", "/m")
-	openRegeditCmd.SysProcAttr = &syscall.SysProcAttr{
-		CreationFlags: FLAG_CREATE_NO_WINDOW,
+	return p == len(pattern)
+}
+
+// MatchPathPattern matches a backslash separated key path against a pattern
+// whose segments are wildcards and where "**" spans any number of segments.
+func MatchPathPattern(pattern string, path string) bool {
+
+	return matchSegments(strings.Split(pattern, "\\"), strings.Split(path, "\\"))
+}
+
+func matchSegments(patterns []string, segments []string) bool {
+
+	for len(patterns) > 0 {
+
+		if patterns[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(patterns[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segments) == 0 || !MatchWildcard(patterns[0], segments[0]) {
+			return false
+		}
+		patterns, segments = patterns[1:], segments[1:]
 	}
 
-	err = openRegeditCmd.Run()
-	if err != nil {
-		log.Println("OpenRegeditAtPath failed to open regedit", err.Error())
+	return len(segments) == 0
+}
+
+func lowerASCII(c byte) byte {
+
+	if c >= 'A' && c <= 'Z' {
+		return c + ('a' - 'A')
 	}
+	return c
 }
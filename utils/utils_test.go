@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestMultiSZToStringSlice(t *testing.T) {
+
+	tests := []struct {
+		name string
+		data []byte
+		want []string
+	}{
+		{"two strings", multiSZ("rundll32.exe shell32.dll, Control_RunDLL", "C:\\b.exe"), []string{"rundll32.exe shell32.dll, Control_RunDLL", "C:\\b.exe"}},
+		{"non-ASCII", multiSZ("C:\\Ā\\a.exe", "Ű"), []string{"C:\\Ā\\a.exe", "Ű"}},
+		{"empty string in between", multiSZ("a", "", "b"), []string{"a", "b"}},
+		{"missing final terminator", multiSZ("a", "b")[:6], []string{"a", "b"}},
+		{"odd length", append(multiSZ("a"), 0x41), []string{"a"}},
+		{"empty", nil, nil},
+	}
+
+	for _, tt := range tests {
+		if got := MultiSZToStringSlice(tt.data); strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// multiSZ encodes strs as REG_MULTI_SZ data: NUL terminated UTF-16 strings
+// followed by an empty one.
+func multiSZ(strs ...string) []byte {
+
+	data := make([]byte, 0)
+	for _, s := range append(strs, "") {
+		for _, u := range utf16.Encode([]rune(s + "\x00")) {
+			data = binary.LittleEndian.AppendUint16(data, u)
+		}
+	}
+	return data
+}